@@ -2,53 +2,111 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/repository"
 	"github.com/frederic-gendebien/pact-poc/application/projection/internal/infrastructure/persistence"
 	handlers "github.com/frederic-gendebien/pact-poc/application/projection/internal/interfaces/eventbus"
 	"github.com/frederic-gendebien/pact-poc/application/projection/internal/interfaces/http"
 	"github.com/frederic-gendebien/pact-poc/application/projection/internal/usecase"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/events"
 	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/frederic-gendebien/pact-poc/lib/config/reload"
 	"github.com/frederic-gendebien/pact-poc/lib/eventbus"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/archive"
+	"github.com/frederic-gendebien/pact-poc/lib/health"
+	"github.com/frederic-gendebien/pact-poc/lib/lifecycle"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/tracing"
 	"log"
+	"os"
+)
+
+const (
+	MaxConsumerLag = "HEALTH_MAX_CONSUMER_LAG"
 )
 
 var (
 	configuration config.Configuration
-	repo          repository.UserRepository
+	logger        logging.Logger
+	tracer        *tracing.Provider
+	repo          repository.SwappableUserRepository
 	eventBus      eventbus.EventBus
+	eventArchive  archive.Archive
 	useCase       usecase.UserProjectionUseCase
+	rebuild       usecase.RebuildUseCase
+	registry      *handlers.Registry
 	server        *http.Server
 )
 
 func init() {
+	config.Register(config.Property{
+		Name:        MaxConsumerLag,
+		Type:        config.TypeInt,
+		Default:     "1000",
+		Description: "consumer lag above which the service is not ready",
+	})
+
 	configuration = config.NewConfiguration()
-	repo = persistence.NewUserRepository(configuration)
-	eventBus = eventbus.NewEventBus(configuration)
-	useCase = usecase.NewUserProjectionUseCase(repo)
-	server = http.NewServer(useCase)
+	if err := config.Validate(configuration); err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	logger = logging.NewLogger(configuration).With(logging.Service, http.ServiceName)
+	tracer = tracing.NewProvider(configuration, logger, http.ServiceName)
+	repo = persistence.NewSwappableUserRepository(persistence.NewUserRepository(configuration, logger), logger)
+	eventArchive = archive.NewArchive(configuration, logger)
+	eventBus = eventbus.WithArchive(eventbus.NewEventBus(configuration, logger), eventArchive, logger)
+	useCase = usecase.NewTracedUserProjectionUseCase(usecase.NewUserProjectionUseCase(persistence.NewInstrumentedUserRepository(repo), logger))
+	registry = handlers.NewRegistry(useCase, logger)
+	if err := registry.Validate(events.All()...); err != nil {
+		logger.Fatalf("could not validate event handlers: %v", err)
+	}
+	rebuild = usecase.NewRebuildUseCase(eventArchive, repo, func() (repository.UserRepository, error) {
+		return persistence.NewStagingUserRepository(configuration, logger)
+	}, handlers.Handlers(logger), logger)
+	server = http.NewServer(configuration, useCase, rebuild, registry.Subscriptions(), health.NewHealth(configuration, logger).
+		Add("repository", repo).
+		Add("eventbus", eventBus).
+		Add("consumer lag", health.NewLagChecker(eventBus, registry.ListenerName(), maxConsumerLag())), logger)
 }
 
 func main() {
-	defer teardown()
-
-	go func() {
-		log.Println("start consuming events")
-		if err := eventBus.Listen(context.Background(),
-			handlers.ListenerName,
-			handlers.NewUserRegisteredHandler(useCase),
-			handlers.UserDeletedHandler(useCase),
-		); err != nil {
-			log.Fatalln("could not listen for events: ", err)
-		}
-	}()
-
-	log.Println("starting server...")
-	log.Fatalln(server.Start())
+	manager := lifecycle.NewManager(configuration, logger).
+		Add("configuration", lifecycle.Closer(configuration)).
+		Add("tracer", lifecycle.NewComponent(nil, tracer.Shutdown)).
+		Add("configuration watcher", lifecycle.Routine(reload.NewWatcher(configuration, logger).Run)).
+		Add("repository", lifecycle.Closer(repo)).
+		Add("event archive", lifecycle.Closer(eventArchive)).
+		Add("eventbus", lifecycle.Closer(eventBus)).
+		Add("event listener", lifecycle.Routine(listen)).
+		Add("http server", server)
+
+	if err := config.PrintEffectiveIfRequested(configuration, os.Stdout); err != nil {
+		logger.Warnf("could not print effective configuration: %v", err)
+	}
+
+	logger.Infof("starting server...")
+	if err := manager.Run(); err != nil {
+		logger.Fatalf("%v", err)
+	}
 }
 
-func teardown() {
-	log.Println("tearing down server resources")
-	_ = repo.Close()
-	_ = eventBus.Close()
-	_ = configuration.Close()
+func maxConsumerLag() int {
+	maxLag, err := config.Int(configuration, MaxConsumerLag)
+	if err != nil {
+		logger.Fatalf("invalid max consumer lag: %v", err)
+	}
+
+	return maxLag
+}
+
+func listen(ctx context.Context) error {
+	logger.With(logging.Listener, registry.ListenerName()).Infof("start consuming events")
+	if err := eventBus.Listen(ctx,
+		registry.ListenerName(),
+		registry.Handlers()...,
+	); err != nil {
+		return fmt.Errorf("could not listen for events: %w", err)
+	}
+
+	return nil
 }
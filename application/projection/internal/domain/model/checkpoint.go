@@ -0,0 +1,53 @@
+package model
+
+func NewCheckpoint(eventId string, sequence int) Checkpoint {
+	return Checkpoint{
+		EventId:  eventId,
+		Sequence: sequence,
+	}
+}
+
+type Checkpoint struct {
+	EventId  string `json:"event_id"`
+	Sequence int    `json:"sequence"`
+	Deleted  bool   `json:"deleted,omitempty"`
+}
+
+// Admit tells whether the next event can be applied after this checkpoint.
+// Events are ordered by their sequence within the entity, which the producer
+// assigns. An event older than a deletion is dropped, as is a deletion older
+// than the current state, while an older event of a live entity still fills in
+// what the more recent ones left out, see Supersedes.
+func (c Checkpoint) Admit(next Checkpoint) error {
+	switch {
+	case c.EventId != "" && c.EventId == next.EventId:
+		return NewSkippedEventError(next.EventId, "event has already been processed")
+	case c.Deleted && next.Sequence <= c.Sequence:
+		return NewSkippedEventError(next.EventId, "entity has been deleted after this event")
+	case next.Deleted && c.Supersedes(next):
+		return NewSkippedEventError(next.EventId, "a more recent event has already been processed")
+	default:
+		return nil
+	}
+}
+
+// Supersedes tells whether this checkpoint is more recent than the next event,
+// which was delivered out of order.
+func (c Checkpoint) Supersedes(next Checkpoint) bool {
+	return next.Sequence < c.Sequence
+}
+
+// Then returns the checkpoint reached once the next event is applied.
+func (c Checkpoint) Then(next Checkpoint) Checkpoint {
+	if c.Supersedes(next) {
+		return c
+	}
+
+	return next
+}
+
+func (c Checkpoint) Tombstone() Checkpoint {
+	c.Deleted = true
+
+	return c
+}
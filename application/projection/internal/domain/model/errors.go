@@ -1,5 +1,9 @@
 package model
 
+import (
+	"fmt"
+)
+
 func NewBadRequest(message string) BadRequestError {
 	return BadRequestError{Message: message}
 }
@@ -57,3 +61,25 @@ func (b UnknownError) Is(err error) bool {
 
 	return ok
 }
+
+func NewSkippedEventError(eventId string, reason string) SkippedEventError {
+	return SkippedEventError{
+		EventId: eventId,
+		Reason:  reason,
+	}
+}
+
+type SkippedEventError struct {
+	EventId string `json:"event_id"`
+	Reason  string `json:"reason"`
+}
+
+func (s SkippedEventError) Error() string {
+	return fmt.Sprintf("event %s was skipped: %s", s.EventId, s.Reason)
+}
+
+func (s SkippedEventError) Is(err error) bool {
+	_, ok := err.(SkippedEventError)
+
+	return ok
+}
@@ -0,0 +1,24 @@
+package model
+
+const (
+	OrphanUnknownUser     = "unknown user"
+	OrphanMissingDocument = "term not referenced by user"
+	OrphanMissingPosting  = "user term not indexed"
+	OrphanEmptyTerm       = "term without users"
+)
+
+type IndexOrphan struct {
+	Term   string `json:"term"`
+	UserId UserId `json:"user_id,omitempty"`
+	Reason string `json:"reason"`
+}
+
+type IndexReport struct {
+	Terms   int           `json:"terms"`
+	Users   int           `json:"users"`
+	Orphans []IndexOrphan `json:"orphans"`
+}
+
+func (r IndexReport) IsConsistent() bool {
+	return len(r.Orphans) == 0
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+type RebuildState string
+
+const (
+	RebuildIdle      RebuildState = "idle"
+	RebuildRunning   RebuildState = "running"
+	RebuildCompleted RebuildState = "completed"
+	RebuildFailed    RebuildState = "failed"
+)
+
+type RebuildStatus struct {
+	State      RebuildState `json:"state"`
+	Total      int          `json:"total"`
+	Replayed   int          `json:"replayed"`
+	Skipped    int          `json:"skipped"`
+	Failures   int          `json:"failures"`
+	StartedAt  *time.Time   `json:"started_at,omitempty"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+	LastError  string       `json:"last_error,omitempty"`
+}
+
+func (r RebuildStatus) IsRunning() bool {
+	return r.State == RebuildRunning
+}
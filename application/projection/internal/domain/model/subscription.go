@@ -0,0 +1,13 @@
+package model
+
+type Subscription struct {
+	Domain  string `json:"domain"`
+	Name    string `json:"name"`
+	Handled bool   `json:"handled"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type ListenerSubscriptions struct {
+	Listener      string         `json:"listener"`
+	Subscriptions []Subscription `json:"subscriptions"`
+}
@@ -3,12 +3,20 @@ package repository
 import (
 	"context"
 	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/health"
 	"io"
 )
 
 type UserRepository interface {
 	io.Closer
-	IndexUser(ctx context.Context, user model.User) error
-	DeleteUserById(ctx context.Context, userId model.UserId) error
+	health.Checker
+	IndexUser(ctx context.Context, user model.User, checkpoint model.Checkpoint) error
+	DeleteUserById(ctx context.Context, userId model.UserId, checkpoint model.Checkpoint) error
 	FindUsersByText(ctx context.Context, text string) ([]model.User, error)
+	CheckIndex(ctx context.Context) (model.IndexReport, error)
+}
+
+type SwappableUserRepository interface {
+	UserRepository
+	Swap(prepare func(current UserRepository) (UserRepository, error)) error
 }
@@ -0,0 +1,244 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/infrastructure/persistence/search"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"go.etcd.io/bbolt"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	usersBucket       = []byte("users")
+	checkpointsBucket = []byte("checkpoints")
+)
+
+const (
+	stagingSuffix = ".rebuild"
+)
+
+func NewUserRepository(path string, logger logging.Logger) (*UserRepository, error) {
+	logger.Infof("starting bolt user repository: %s", path)
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("could not open bolt database %s: %v", path, err)
+	}
+
+	repository := &UserRepository{
+		db:     db,
+		logger: logger,
+		lock:   &sync.RWMutex{},
+		index:  search.NewIndex(),
+	}
+
+	if err := repository.load(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("could not initialize bolt database %s: %v", path, err)
+	}
+
+	return repository, nil
+}
+
+func NewStagingUserRepository(path string, logger logging.Logger) (*UserRepository, error) {
+	stagingPath := path + stagingSuffix
+	if err := os.Remove(stagingPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not remove previous staging database %s: %v", stagingPath, err)
+	}
+
+	repository, err := NewUserRepository(stagingPath, logger)
+	if err != nil {
+		return nil, err
+	}
+	repository.promotePath = path
+
+	return repository, nil
+}
+
+type UserRepository struct {
+	db          *bbolt.DB
+	logger      logging.Logger
+	lock        *sync.RWMutex
+	index       *search.Index
+	promotePath string
+}
+
+func (u *UserRepository) Close() error {
+	u.logger.Infof("closing bolt user repository")
+
+	return u.db.Close()
+}
+
+func (u *UserRepository) CheckHealth(ctx context.Context) error {
+	return u.db.View(func(tx *bbolt.Tx) error {
+		return nil
+	})
+}
+
+func (u *UserRepository) Promote() error {
+	if u.promotePath == "" {
+		return nil
+	}
+
+	u.logger.Infof("promoting bolt user repository %s to %s", u.db.Path(), u.promotePath)
+
+	return os.Rename(u.db.Path(), u.promotePath)
+}
+
+func (u *UserRepository) IndexUser(ctx context.Context, user model.User, checkpoint model.Checkpoint) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	resultingUser := user
+	if err := u.db.Update(func(tx *bbolt.Tx) error {
+		current := model.Checkpoint{}
+		if _, err := get(tx, checkpointsBucket, string(user.Id), &current); err != nil {
+			return err
+		}
+
+		if err := current.Admit(checkpoint); err != nil {
+			return err
+		}
+
+		existingUser := model.User{}
+		found, err := get(tx, usersBucket, string(user.Id), &existingUser)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case found && current.Supersedes(checkpoint):
+			resultingUser = user.UpdateWith(existingUser)
+		case found:
+			resultingUser = existingUser.UpdateWith(user)
+		}
+
+		if err := put(tx, usersBucket, string(user.Id), resultingUser); err != nil {
+			return err
+		}
+
+		return put(tx, checkpointsBucket, string(user.Id), current.Then(checkpoint))
+	}); err != nil {
+		return err
+	}
+
+	u.index.Put(resultingUser.Id, search.UserFields(resultingUser)...)
+
+	return nil
+}
+
+func (u *UserRepository) DeleteUserById(ctx context.Context, userId model.UserId, checkpoint model.Checkpoint) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if err := u.db.Update(func(tx *bbolt.Tx) error {
+		current := model.Checkpoint{}
+		if _, err := get(tx, checkpointsBucket, string(userId), &current); err != nil {
+			return err
+		}
+
+		deletion := checkpoint.Tombstone()
+		if err := current.Admit(deletion); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket(usersBucket).Delete([]byte(userId)); err != nil {
+			return err
+		}
+
+		return put(tx, checkpointsBucket, string(userId), deletion)
+	}); err != nil {
+		return err
+	}
+
+	u.index.Remove(userId)
+
+	return nil
+}
+
+func (u *UserRepository) FindUsersByText(ctx context.Context, text string) ([]model.User, error) {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	matches := u.index.Search(text)
+	users := make([]model.User, 0, len(matches))
+	err := u.db.View(func(tx *bbolt.Tx) error {
+		for _, match := range matches {
+			user := model.User{}
+			found, err := get(tx, usersBucket, string(match.UserId), &user)
+			if err != nil {
+				return err
+			}
+
+			if found {
+				users = append(users, user)
+			}
+		}
+
+		return nil
+	})
+
+	return users, err
+}
+
+func (u *UserRepository) CheckIndex(ctx context.Context) (model.IndexReport, error) {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	known := make(map[model.UserId]bool)
+	if err := u.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(usersBucket).ForEach(func(key []byte, value []byte) error {
+			known[model.UserId(key)] = true
+			return nil
+		})
+	}); err != nil {
+		return model.IndexReport{}, err
+	}
+
+	return u.index.Check(func(userId model.UserId) bool {
+		return known[userId]
+	}), nil
+}
+
+func (u *UserRepository) load() error {
+	return u.db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{usersBucket, checkpointsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+
+		return tx.Bucket(usersBucket).ForEach(func(key []byte, value []byte) error {
+			user := model.User{}
+			if err := json.Unmarshal(value, &user); err != nil {
+				return err
+			}
+
+			u.index.Put(user.Id, search.UserFields(user)...)
+
+			return nil
+		})
+	})
+}
+
+func get(tx *bbolt.Tx, bucket []byte, key string, value interface{}) (bool, error) {
+	data := tx.Bucket(bucket).Get([]byte(key))
+	if data == nil {
+		return false, nil
+	}
+
+	return true, json.Unmarshal(data, value)
+}
+
+func put(tx *bbolt.Tx, bucket []byte, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(bucket).Put([]byte(key), data)
+}
@@ -0,0 +1,126 @@
+package bolt
+
+import (
+	"context"
+	"errors"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"path/filepath"
+	"testing"
+)
+
+func TestUserRepository_Checkpoints(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "projection.db")
+	repository := newTestRepository(t, path)
+
+	registered := model.NewCheckpoint("event1", 1)
+	if err := repository.IndexUser(ctx, model.User{Id: "user1", Name: "Alice Martin", Email: "alice@example.com"}, registered); err != nil {
+		t.Fatalf("could not index user: %v", err)
+	}
+
+	if err := repository.IndexUser(ctx, model.User{Id: "user1", Name: "Duplicate"}, registered); !errors.Is(err, model.SkippedEventError{}) {
+		t.Fatalf("expected duplicate event to be skipped, but got: %v", err)
+	}
+
+	if err := repository.Close(); err != nil {
+		t.Fatalf("could not close repository: %v", err)
+	}
+
+	repository = newTestRepository(t, path)
+	defer repository.Close()
+
+	if users, err := repository.FindUsersByText(ctx, "alice"); err != nil || len(users) != 1 || users[0].Name != "Alice Martin" {
+		t.Fatalf("expected indexed user after reopening, but got: %v, %v", users, err)
+	}
+
+	if err := repository.IndexUser(ctx, model.User{Id: "user1", Name: "Duplicate"}, registered); !errors.Is(err, model.SkippedEventError{}) {
+		t.Fatalf("expected duplicate event to be skipped after reopening, but got: %v", err)
+	}
+
+	if err := repository.DeleteUserById(ctx, "user1", model.NewCheckpoint("event2", 2)); err != nil {
+		t.Fatalf("could not delete user: %v", err)
+	}
+
+	if err := repository.IndexUser(ctx, model.User{Id: "user1", Name: "Resurrected"}, model.NewCheckpoint("event0", 1)); !errors.Is(err, model.SkippedEventError{}) {
+		t.Fatalf("expected deleted user not to be resurrected, but got: %v", err)
+	}
+
+	if users, err := repository.FindUsersByText(ctx, "alice"); err != nil || len(users) != 0 {
+		t.Fatalf("expected no users after deletion, but got: %v, %v", users, err)
+	}
+
+	if report, err := repository.CheckIndex(ctx); err != nil || !report.IsConsistent() {
+		t.Fatalf("expected a consistent index, but got: %+v, %v", report, err)
+	}
+}
+
+func TestUserRepository_RegisteredAfterCorrection(t *testing.T) {
+	ctx := context.Background()
+	repository := newTestRepository(t, filepath.Join(t.TempDir(), "projection.db"))
+	defer repository.Close()
+
+	if err := repository.IndexUser(ctx, model.User{Id: "user1", Name: "Alice Durand"}, model.NewCheckpoint("event2", 2)); err != nil {
+		t.Fatalf("could not index corrected user: %v", err)
+	}
+
+	if err := repository.IndexUser(ctx, model.User{Id: "user1", Name: "Alice Martin", Email: "alice@example.com"}, model.NewCheckpoint("event1", 1)); err != nil {
+		t.Fatalf("expected registration delivered late to be applied, but got: %v", err)
+	}
+
+	if users, err := repository.FindUsersByText(ctx, "alice@example.com"); err != nil || len(users) != 1 || users[0].Name != "Alice Durand" {
+		t.Fatalf("expected corrected user to be found by email, but got: %v, %v", users, err)
+	}
+
+	if err := repository.DeleteUserById(ctx, "user1", model.NewCheckpoint("event0", 1)); !errors.Is(err, model.SkippedEventError{}) {
+		t.Fatalf("expected deletion older than the user to be skipped, but got: %v", err)
+	}
+
+	if users, err := repository.FindUsersByText(ctx, "durand"); err != nil || len(users) != 1 {
+		t.Fatalf("expected user to survive an older deletion, but got: %v, %v", users, err)
+	}
+}
+
+func TestUserRepository_RegisteredAgainAfterDeletion(t *testing.T) {
+	ctx := context.Background()
+	repository := newTestRepository(t, filepath.Join(t.TempDir(), "projection.db"))
+	defer repository.Close()
+
+	registered := model.NewCheckpoint("event1", 1)
+	if err := repository.IndexUser(ctx, model.User{Id: "user1", Name: "Alice Martin", Email: "alice@example.com"}, registered); err != nil {
+		t.Fatalf("could not index user: %v", err)
+	}
+
+	if err := repository.DeleteUserById(ctx, "user1", model.NewCheckpoint("event2", 2)); err != nil {
+		t.Fatalf("could not delete user: %v", err)
+	}
+
+	if err := repository.IndexUser(ctx, model.User{Id: "user1", Name: "Alice Martin"}, registered); !errors.Is(err, model.SkippedEventError{}) {
+		t.Fatalf("expected redelivered registration to be skipped, but got: %v", err)
+	}
+
+	if err := repository.IndexUser(ctx, model.User{Id: "user1", Name: "Bob Martin"}, model.NewCheckpoint("event4", 4)); err != nil {
+		t.Fatalf("expected correction of the registered user to be applied, but got: %v", err)
+	}
+
+	if err := repository.IndexUser(ctx, model.User{Id: "user1", Name: "Bob Durand", Email: "bob@example.com"}, model.NewCheckpoint("event3", 3)); err != nil {
+		t.Fatalf("expected newer registration to clear the deletion, but got: %v", err)
+	}
+
+	if users, err := repository.FindUsersByText(ctx, "bob"); err != nil || len(users) != 1 || users[0].Name != "Bob Martin" || users[0].Email != "bob@example.com" {
+		t.Fatalf("expected user registered again to be found, but got: %v, %v", users, err)
+	}
+
+	if users, err := repository.FindUsersByText(ctx, "alice"); err != nil || len(users) != 0 {
+		t.Fatalf("expected no trace of the deleted user, but got: %v, %v", users, err)
+	}
+}
+
+func newTestRepository(t *testing.T, path string) *UserRepository {
+	repository, err := NewUserRepository(path, logging.NewDiscardLogger())
+	if err != nil {
+		t.Fatalf("could not open repository: %v", err)
+	}
+
+	return repository
+}
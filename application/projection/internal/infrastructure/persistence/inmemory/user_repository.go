@@ -2,81 +2,107 @@ package inmemory
 
 import (
 	"context"
-	"fmt"
 	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
-	"log"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/infrastructure/persistence/search"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
 	"sync"
 )
 
-func NewUserRepository() *UserRepository {
+func NewUserRepository(logger logging.Logger) *UserRepository {
 	return &UserRepository{
-		lock:     &sync.RWMutex{},
-		patterns: make(map[string][]model.UserId),
-		users:    make(map[model.UserId]model.User),
+		logger:      logger,
+		lock:        &sync.RWMutex{},
+		index:       search.NewIndex(),
+		users:       make(map[model.UserId]model.User),
+		checkpoints: make(map[model.UserId]model.Checkpoint),
 	}
 }
 
 type UserRepository struct {
-	lock     *sync.RWMutex
-	patterns map[string][]model.UserId
-	users    map[model.UserId]model.User
+	logger      logging.Logger
+	lock        *sync.RWMutex
+	index       *search.Index
+	users       map[model.UserId]model.User
+	checkpoints map[model.UserId]model.Checkpoint
 }
 
 func (u *UserRepository) Close() error {
-	log.Println("closing inmemory user repository")
+	u.logger.Infof("closing inmemory user repository")
 
 	return nil
 }
 
-func (u *UserRepository) IndexUser(ctx context.Context, user model.User) error {
+func (u *UserRepository) CheckHealth(ctx context.Context) error {
+	return nil
+}
+
+func (u *UserRepository) IndexUser(ctx context.Context, user model.User, checkpoint model.Checkpoint) error {
 	u.lock.Lock()
 	defer u.lock.Unlock()
 
+	current := u.checkpoints[user.Id]
+	if err := current.Admit(checkpoint); err != nil {
+		return err
+	}
+
 	resultingUser, present := u.users[user.Id]
-	if present {
+	switch {
+	case present && current.Supersedes(checkpoint):
+		resultingUser = user.UpdateWith(resultingUser)
+	case present:
 		resultingUser = resultingUser.UpdateWith(user)
-	} else {
+	default:
 		resultingUser = user
 	}
 
 	u.users[resultingUser.Id] = resultingUser
-	u.addPattern(ctx, resultingUser.Id, resultingUser.Name)
-	u.addPattern(ctx, resultingUser.Id, string(resultingUser.Email))
+	u.checkpoints[resultingUser.Id] = current.Then(checkpoint)
+	u.index.Put(resultingUser.Id, search.UserFields(resultingUser)...)
 
 	return nil
 }
 
-func (u *UserRepository) addPattern(ctx context.Context, userId model.UserId, text string) {
-	if text != "" {
-		u.patterns[text] = append(u.patterns[text], userId)
-	}
-}
-
-func (u *UserRepository) DeleteUserById(ctx context.Context, userId model.UserId) error {
+func (u *UserRepository) DeleteUserById(ctx context.Context, userId model.UserId, checkpoint model.Checkpoint) error {
 	u.lock.Lock()
 	defer u.lock.Unlock()
 
-	if _, present := u.users[userId]; present {
-		delete(u.users, userId)
+	deletion := checkpoint.Tombstone()
+	if err := u.checkpoints[userId].Admit(deletion); err != nil {
+		return err
+	}
 
-		return nil
+	if _, present := u.users[userId]; !present {
+		u.logger.WithContext(ctx).With(logging.UserId, userId).Infof("user was not found, recording its deletion")
 	}
 
-	return model.NewNotFoundError(fmt.Sprintf("user with id: %s was not found", userId))
+	delete(u.users, userId)
+	u.index.Remove(userId)
+	u.checkpoints[userId] = deletion
+
+	return nil
 }
 
 func (u *UserRepository) FindUsersByText(ctx context.Context, text string) ([]model.User, error) {
 	u.lock.RLock()
 	defer u.lock.RUnlock()
 
-	users := make([]model.User, 0, 2)
-	if userIds := u.patterns[text]; userIds != nil {
-		for _, userId := range userIds {
-			if user, present := u.users[userId]; present {
-				users = append(users, user)
-			}
+	matches := u.index.Search(text)
+	users := make([]model.User, 0, len(matches))
+	for _, match := range matches {
+		if user, present := u.users[match.UserId]; present {
+			users = append(users, user)
 		}
 	}
 
 	return users, nil
 }
+
+func (u *UserRepository) CheckIndex(ctx context.Context) (model.IndexReport, error) {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	return u.index.Check(func(userId model.UserId) bool {
+		_, present := u.users[userId]
+		return present
+	}), nil
+}
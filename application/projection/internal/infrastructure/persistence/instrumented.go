@@ -0,0 +1,62 @@
+package persistence
+
+import (
+	"context"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/repository"
+	"github.com/frederic-gendebien/pact-poc/lib/metrics"
+	"github.com/frederic-gendebien/pact-poc/lib/tracing"
+	"time"
+)
+
+const (
+	instrumentedRepositoryName = "user_projection"
+)
+
+func NewInstrumentedUserRepository(userRepository repository.UserRepository) *InstrumentedUserRepository {
+	return &InstrumentedUserRepository{
+		UserRepository: userRepository,
+	}
+}
+
+type InstrumentedUserRepository struct {
+	repository.UserRepository
+}
+
+func (i *InstrumentedUserRepository) IndexUser(ctx context.Context, user model.User, checkpoint model.Checkpoint) (err error) {
+	ctx, done := observe(ctx, "index_user")
+	defer done(&err)
+
+	return i.UserRepository.IndexUser(ctx, user, checkpoint)
+}
+
+func (i *InstrumentedUserRepository) DeleteUserById(ctx context.Context, userId model.UserId, checkpoint model.Checkpoint) (err error) {
+	ctx, done := observe(ctx, "delete_user")
+	defer done(&err)
+
+	return i.UserRepository.DeleteUserById(ctx, userId, checkpoint)
+}
+
+func (i *InstrumentedUserRepository) FindUsersByText(ctx context.Context, text string) (users []model.User, err error) {
+	ctx, done := observe(ctx, "find_users_by_text")
+	defer done(&err)
+
+	return i.UserRepository.FindUsersByText(ctx, text)
+}
+
+func (i *InstrumentedUserRepository) CheckIndex(ctx context.Context) (report model.IndexReport, err error) {
+	ctx, done := observe(ctx, "check_index")
+	defer done(&err)
+
+	return i.UserRepository.CheckIndex(ctx)
+}
+
+func observe(ctx context.Context, operation string) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, instrumentedRepositoryName+" repository "+operation)
+
+	return ctx, func(err *error) {
+		tracing.End(span, *err)
+		metrics.ObserveRepositoryOperation(instrumentedRepositoryName, operation, start, *err)
+	}
+}
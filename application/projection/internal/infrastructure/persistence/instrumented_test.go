@@ -0,0 +1,43 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/infrastructure/persistence/inmemory"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/metrics"
+	"github.com/frederic-gendebien/pact-poc/lib/metrics/metricstest"
+	"testing"
+)
+
+func TestInstrumentedUserRepository(t *testing.T) {
+	ctx := context.Background()
+	instrumented := NewInstrumentedUserRepository(inmemory.NewUserRepository(logging.NewDiscardLogger()))
+	indexed, skipped := observations(t, "index_user", metrics.OutcomeSuccess), observations(t, "index_user", metrics.OutcomeFailure)
+
+	user := model.User{Id: "user1", Name: "Alice Martin", Email: "alice@example.com"}
+	if err := instrumented.IndexUser(ctx, user, model.NewCheckpoint("event1", 1)); err != nil {
+		t.Fatalf("could not index user: %v", err)
+	}
+
+	if err := instrumented.IndexUser(ctx, user, model.NewCheckpoint("event1", 1)); !errors.Is(err, model.SkippedEventError{}) {
+		t.Fatalf("a %v was expected, but found: %v", model.SkippedEventError{}, err)
+	}
+
+	if count := observations(t, "index_user", metrics.OutcomeSuccess) - indexed; count != 1 {
+		t.Fatalf("expected 1 successful index_user observation, but got: %d", count)
+	}
+
+	if count := observations(t, "index_user", metrics.OutcomeFailure) - skipped; count != 1 {
+		t.Fatalf("expected 1 failed index_user observation, but got: %d", count)
+	}
+}
+
+func observations(t *testing.T, operation string, outcome string) uint64 {
+	return metricstest.SampleCount(t, "repository_operation_duration_seconds", map[string]string{
+		"repository": instrumentedRepositoryName,
+		"operation":  operation,
+		"outcome":    outcome,
+	})
+}
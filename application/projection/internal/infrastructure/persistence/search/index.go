@@ -0,0 +1,255 @@
+package search
+
+import (
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"sort"
+	"strings"
+)
+
+const (
+	exactScore  = 1.0
+	prefixScore = 0.8
+	fuzzyScore  = 0.5
+
+	nameWeight  = 2.0
+	emailWeight = 1.0
+)
+
+type Field struct {
+	Weight float64
+	Text   string
+}
+
+type Match struct {
+	UserId model.UserId
+	Score  float64
+}
+
+func NewIndex() *Index {
+	return &Index{
+		postings:  make(map[string]map[model.UserId]float64),
+		documents: make(map[model.UserId]map[string]float64),
+	}
+}
+
+type Index struct {
+	postings  map[string]map[model.UserId]float64
+	documents map[model.UserId]map[string]float64
+	terms     []string
+}
+
+func (i *Index) Put(userId model.UserId, fields ...Field) {
+	i.Remove(userId)
+
+	document := make(map[string]float64)
+	for _, field := range fields {
+		for _, term := range Tokenize(field.Text) {
+			document[term] += field.Weight
+		}
+	}
+
+	if len(document) == 0 {
+		return
+	}
+
+	for term, weight := range document {
+		users, present := i.postings[term]
+		if !present {
+			users = make(map[model.UserId]float64)
+			i.postings[term] = users
+			i.insertTerm(term)
+		}
+
+		users[userId] = weight
+	}
+	i.documents[userId] = document
+}
+
+func (i *Index) Remove(userId model.UserId) {
+	for term := range i.documents[userId] {
+		users := i.postings[term]
+		delete(users, userId)
+		if len(users) == 0 {
+			delete(i.postings, term)
+			i.removeTerm(term)
+		}
+	}
+	delete(i.documents, userId)
+}
+
+func (i *Index) Clear() {
+	i.postings = make(map[string]map[model.UserId]float64)
+	i.documents = make(map[model.UserId]map[string]float64)
+	i.terms = nil
+}
+
+func (i *Index) Check(known func(userId model.UserId) bool) model.IndexReport {
+	orphans := make([]model.IndexOrphan, 0)
+	for _, term := range i.terms {
+		users := i.postings[term]
+		if len(users) == 0 {
+			orphans = append(orphans, model.IndexOrphan{Term: term, Reason: model.OrphanEmptyTerm})
+		}
+
+		for _, userId := range sortedUserIds(users) {
+			switch _, referenced := i.documents[userId][term]; {
+			case !known(userId):
+				orphans = append(orphans, model.IndexOrphan{Term: term, UserId: userId, Reason: model.OrphanUnknownUser})
+			case !referenced:
+				orphans = append(orphans, model.IndexOrphan{Term: term, UserId: userId, Reason: model.OrphanMissingDocument})
+			}
+		}
+	}
+
+	documentIds := make(map[model.UserId]float64, len(i.documents))
+	for userId := range i.documents {
+		documentIds[userId] = 0
+	}
+
+	for _, userId := range sortedUserIds(documentIds) {
+		for term := range i.documents[userId] {
+			if _, indexed := i.postings[term][userId]; !indexed {
+				orphans = append(orphans, model.IndexOrphan{Term: term, UserId: userId, Reason: model.OrphanMissingPosting})
+			}
+		}
+	}
+
+	return model.IndexReport{
+		Terms:   len(i.terms),
+		Users:   len(i.documents),
+		Orphans: orphans,
+	}
+}
+
+func (i *Index) Search(text string) []Match {
+	queryTerms := Tokenize(text)
+	if len(queryTerms) == 0 {
+		return []Match{}
+	}
+
+	var scores map[model.UserId]float64
+	for _, queryTerm := range queryTerms {
+		termScores := i.scoreTerm(queryTerm)
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+
+		for userId, score := range scores {
+			if termScore, matched := termScores[userId]; matched {
+				scores[userId] = score + termScore
+			} else {
+				delete(scores, userId)
+			}
+		}
+	}
+
+	matches := make([]Match, 0, len(scores))
+	for userId, score := range scores {
+		matches = append(matches, Match{UserId: userId, Score: score})
+	}
+
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].Score != matches[b].Score {
+			return matches[a].Score > matches[b].Score
+		}
+
+		return matches[a].UserId < matches[b].UserId
+	})
+
+	return matches
+}
+
+func (i *Index) scoreTerm(queryTerm string) map[model.UserId]float64 {
+	scores := make(map[model.UserId]float64)
+	query := []rune(queryTerm)
+	limit := maxDistance(query)
+	for _, term := range i.candidates(queryTerm, limit) {
+		score, matched := termScore(query, term, limit)
+		if !matched {
+			continue
+		}
+
+		for userId, weight := range i.postings[term] {
+			if weighted := score * weight; weighted > scores[userId] {
+				scores[userId] = weighted
+			}
+		}
+	}
+
+	return scores
+}
+
+func termScore(query []rune, term string, limit int) (float64, bool) {
+	candidate := []rune(term)
+	switch {
+	case string(query) == term:
+		return exactScore, true
+	case strings.HasPrefix(term, string(query)):
+		return prefixScore * float64(len(query)) / float64(len(candidate)), true
+	case abs(len(candidate)-len(query)) > limit:
+		return 0, false
+	}
+
+	if edits := distance(query, candidate); edits <= limit {
+		return fuzzyScore * (1 - float64(edits)/float64(len(query)+1)), true
+	}
+
+	return 0, false
+}
+
+func (i *Index) candidates(queryTerm string, limit int) []string {
+	if limit > 0 {
+		return i.terms
+	}
+
+	start := sort.SearchStrings(i.terms, queryTerm)
+	end := start
+	for end < len(i.terms) && strings.HasPrefix(i.terms[end], queryTerm) {
+		end++
+	}
+
+	return i.terms[start:end]
+}
+
+func (i *Index) insertTerm(term string) {
+	position := sort.SearchStrings(i.terms, term)
+	i.terms = append(i.terms, "")
+	copy(i.terms[position+1:], i.terms[position:])
+	i.terms[position] = term
+}
+
+func (i *Index) removeTerm(term string) {
+	position := sort.SearchStrings(i.terms, term)
+	if position < len(i.terms) && i.terms[position] == term {
+		i.terms = append(i.terms[:position], i.terms[position+1:]...)
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
+
+func sortedUserIds(users map[model.UserId]float64) []model.UserId {
+	userIds := make([]model.UserId, 0, len(users))
+	for userId := range users {
+		userIds = append(userIds, userId)
+	}
+
+	sort.Slice(userIds, func(a, b int) bool {
+		return userIds[a] < userIds[b]
+	})
+
+	return userIds
+}
+
+func UserFields(user model.User) []Field {
+	return []Field{
+		{Weight: nameWeight, Text: user.Name},
+		{Weight: emailWeight, Text: string(user.Email)},
+	}
+}
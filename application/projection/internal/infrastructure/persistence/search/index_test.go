@@ -0,0 +1,82 @@
+package search
+
+import (
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenize("Élodie MARTIN-Groß <elodie.martin@example.com>")
+	expected := []string{"elodie", "martin", "gross", "elodie", "martin", "example", "com"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Fatalf("expected: %v, but got %v", expected, tokens)
+	}
+}
+
+func TestIndex_Search(t *testing.T) {
+	index := NewIndex()
+	index.Put("alice", Field{Weight: 2, Text: "Alice Martin"}, Field{Weight: 1, Text: "alice.martin@example.com"})
+	index.Put("alicia", Field{Weight: 2, Text: "Alicia Keys"})
+	index.Put("bob", Field{Weight: 2, Text: "Bob Martín"}, Field{Weight: 1, Text: "bob@example.com"})
+
+	tests := []struct {
+		query    string
+		expected []model.UserId
+	}{
+		{query: "ali", expected: []model.UserId{"alice", "alicia"}},
+		{query: "ALICE", expected: []model.UserId{"alice"}},
+		{query: "martin", expected: []model.UserId{"alice", "bob"}},
+		{query: "alice martin", expected: []model.UserId{"alice"}},
+		{query: "alcie", expected: []model.UserId{"alice"}},
+		{query: "bob@example.com", expected: []model.UserId{"bob"}},
+		{query: "charlie", expected: []model.UserId{}},
+		{query: "@", expected: []model.UserId{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			userIds := make([]model.UserId, 0)
+			for _, match := range index.Search(test.query) {
+				userIds = append(userIds, match.UserId)
+			}
+
+			if !reflect.DeepEqual(userIds, test.expected) {
+				t.Fatalf("expected: %v, but got %v", test.expected, userIds)
+			}
+		})
+	}
+}
+
+func TestIndex_PutReplacesAndRemovePurges(t *testing.T) {
+	index := NewIndex()
+	index.Put("alice", Field{Weight: 2, Text: "Alice Martin"})
+	index.Put("bob", Field{Weight: 2, Text: "Bob Martin"})
+	index.Put("alice", Field{Weight: 2, Text: "Alice Durand"})
+
+	if matches := index.Search("martin"); len(matches) != 1 || matches[0].UserId != "bob" {
+		t.Fatalf("expected only bob to match an old name, but got: %v", matches)
+	}
+
+	index.Remove("bob")
+	if matches := index.Search("bob"); len(matches) != 0 {
+		t.Fatalf("expected no match after removal, but got: %v", matches)
+	}
+
+	known := func(userId model.UserId) bool {
+		return userId == "alice"
+	}
+
+	report := index.Check(known)
+	if !report.IsConsistent() || report.Terms != 2 || report.Users != 1 {
+		t.Fatalf("expected a consistent index with 2 terms and 1 user, but got: %+v", report)
+	}
+
+	index.postings["ghost"] = map[model.UserId]float64{"carol": 1}
+	index.insertTerm("ghost")
+
+	expected := []model.IndexOrphan{{Term: "ghost", UserId: "carol", Reason: model.OrphanUnknownUser}}
+	if report := index.Check(known); !reflect.DeepEqual(report.Orphans, expected) {
+		t.Fatalf("expected: %v, but got %v", expected, report.Orphans)
+	}
+}
@@ -0,0 +1,94 @@
+package persistence
+
+import (
+	"context"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/repository"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"sync"
+)
+
+type promotable interface {
+	Promote() error
+}
+
+func NewSwappableUserRepository(current repository.UserRepository, logger logging.Logger) *SwappableUserRepository {
+	return &SwappableUserRepository{
+		logger:  logger,
+		lock:    &sync.RWMutex{},
+		current: current,
+	}
+}
+
+type SwappableUserRepository struct {
+	logger  logging.Logger
+	lock    *sync.RWMutex
+	current repository.UserRepository
+}
+
+func (s *SwappableUserRepository) Swap(prepare func(current repository.UserRepository) (repository.UserRepository, error)) error {
+	s.lock.Lock()
+	previous := s.current
+	next, err := prepare(previous)
+	if err == nil {
+		s.current = next
+	}
+	s.lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	s.logger.Infof("swapped projection user repository")
+	if err := previous.Close(); err != nil {
+		return err
+	}
+
+	if promotable, ok := next.(promotable); ok {
+		return promotable.Promote()
+	}
+
+	return nil
+}
+
+func (s *SwappableUserRepository) Close() error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.current.Close()
+}
+
+func (s *SwappableUserRepository) CheckHealth(ctx context.Context) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.current.CheckHealth(ctx)
+}
+
+func (s *SwappableUserRepository) IndexUser(ctx context.Context, user model.User, checkpoint model.Checkpoint) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.current.IndexUser(ctx, user, checkpoint)
+}
+
+func (s *SwappableUserRepository) DeleteUserById(ctx context.Context, userId model.UserId, checkpoint model.Checkpoint) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.current.DeleteUserById(ctx, userId, checkpoint)
+}
+
+func (s *SwappableUserRepository) FindUsersByText(ctx context.Context, text string) ([]model.User, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.current.FindUsersByText(ctx, text)
+}
+
+func (s *SwappableUserRepository) CheckIndex(ctx context.Context) (model.IndexReport, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.current.CheckIndex(ctx)
+}
@@ -1,24 +1,78 @@
 package persistence
 
 import (
+	"fmt"
 	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/repository"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/infrastructure/persistence/bolt"
 	"github.com/frederic-gendebien/pact-poc/application/projection/internal/infrastructure/persistence/inmemory"
 	"github.com/frederic-gendebien/pact-poc/lib/config"
-	"log"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
 )
 
 const (
 	Mode         = "PERSISTENCE_MODE"
 	ModeInMemory = "inmemory"
+	ModeBolt     = "bolt"
+	BoltPath     = "PERSISTENCE_BOLT_PATH"
 )
 
-func NewUserRepository(configuration config.Configuration) repository.UserRepository {
-	mode := configuration.GetStringOrCrash(Mode)
+func init() {
+	config.Register(config.Property{
+		Name:        Mode,
+		Required:    true,
+		Allowed:     []string{ModeInMemory, ModeBolt},
+		Description: "user projection repository implementation",
+	})
+	config.Register(config.When(Mode, ModeBolt, config.Property{
+		Name:        BoltPath,
+		Required:    true,
+		Description: "bolt database file",
+	})...)
+}
+
+func NewUserRepository(configuration config.Configuration, logger logging.Logger) repository.UserRepository {
+	mode, err := config.MandatoryString(configuration, Mode)
+	if err != nil {
+		logger.Fatalf("invalid persistence configuration: %v", err)
+	}
+
 	switch mode {
 	case ModeInMemory:
-		return inmemory.NewUserRepository()
+		return inmemory.NewUserRepository(logger)
+	case ModeBolt:
+		path, err := config.MandatoryString(configuration, BoltPath)
+		if err != nil {
+			logger.Fatalf("invalid persistence configuration: %v", err)
+		}
+
+		repository, err := bolt.NewUserRepository(path, logger)
+		if err != nil {
+			logger.Fatalf("could not start bolt user repository: %v", err)
+		}
+		return repository
 	default:
-		log.Fatalf("unknown persistence mode: %s", mode)
+		logger.Fatalf("unknown persistence mode: %s", mode)
 		return nil
 	}
 }
+
+func NewStagingUserRepository(configuration config.Configuration, logger logging.Logger) (repository.UserRepository, error) {
+	mode, err := config.MandatoryString(configuration, Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case ModeInMemory:
+		return inmemory.NewUserRepository(logger), nil
+	case ModeBolt:
+		path, err := config.MandatoryString(configuration, BoltPath)
+		if err != nil {
+			return nil, err
+		}
+
+		return bolt.NewStagingUserRepository(path, logger)
+	default:
+		return nil, fmt.Errorf("unknown persistence mode: %s", mode)
+	}
+}
@@ -5,54 +5,59 @@ import (
 	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
 	"github.com/frederic-gendebien/pact-poc/application/projection/internal/usecase"
 	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/events"
-	eventbus "github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
-	"log"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
 )
 
 const (
 	ListenerName = "projection"
 )
 
-func NewUserRegisteredHandler(useCase usecase.UserProjectionUseCase) eventbus.EventHandler {
+func NewUserRegisteredHandler(useCase usecase.UserProjectionUseCase, logger logging.Logger) domain.EventHandler {
 	return NewListener(
 		events.NewUserRegistered{},
-		func(event interface{}) error {
-			return useCase.IndexUser(context.Background(), projectionUser(event.(*events.NewUserRegistered).User))
+		func(envelope domain.Envelope) error {
+			return useCase.IndexUser(eventbus.CausedBy(context.Background(), envelope), projectionUser(envelope.Event.(*events.NewUserRegistered).User), checkpointOf(envelope))
 		},
-		logError(),
+		logError(logger),
 	)
 }
 
-func UserDetailsCorrectedHandler(useCase usecase.UserProjectionUseCase) eventbus.EventHandler {
+func UserDetailsCorrectedHandler(useCase usecase.UserProjectionUseCase, logger logging.Logger) domain.EventHandler {
 	return NewListener(
 		events.UserDetailsCorrected{},
-		func(event interface{}) error {
-			return useCase.IndexUser(context.Background(), partialUserFrom(event.(*events.UserDetailsCorrected)))
+		func(envelope domain.Envelope) error {
+			return useCase.IndexUser(eventbus.CausedBy(context.Background(), envelope), partialUserFrom(envelope.Event.(*events.UserDetailsCorrected)), checkpointOf(envelope))
 		},
-		logError(),
+		logError(logger),
 	)
 }
 
-func UserDeletedHandler(useCase usecase.UserProjectionUseCase) eventbus.EventHandler {
+func UserDeletedHandler(useCase usecase.UserProjectionUseCase, logger logging.Logger) domain.EventHandler {
 	return NewListener(
 		events.UserDeleted{},
-		func(event interface{}) error {
-			return useCase.DeleteUserById(context.Background(), model.UserId(event.(*events.UserDeleted).UserId))
+		func(envelope domain.Envelope) error {
+			return useCase.DeleteUserById(eventbus.CausedBy(context.Background(), envelope), model.UserId(envelope.Event.(*events.UserDeleted).UserId), checkpointOf(envelope))
 		},
-		logError(),
+		logError(logger),
 	)
 }
 
-func logError() func(event interface{}, err error) {
-	return func(event interface{}, err error) {
-		log.Printf("error processing event: %v: %v", event, err)
+func logError(logger logging.Logger) func(envelope domain.Envelope, err error) {
+	return func(envelope domain.Envelope, err error) {
+		logger.WithContext(eventbus.CausedBy(context.Background(), envelope)).
+			With(logging.Listener, ListenerName).
+			With(logging.EventName, envelope.Metadata.Name).
+			With(logging.UserId, envelope.Metadata.EntityId).
+			Errorf("error processing event: %v", err)
 	}
 }
 
 func NewListener(
-	event eventbus.EventDefinition,
-	handling func(interface{}) error,
-	errorHandling func(interface{}, error),
+	event domain.EventDefinition,
+	handling func(domain.Envelope) error,
+	errorHandling func(domain.Envelope, error),
 ) *Listener {
 	return &Listener{
 		event:         event,
@@ -62,23 +67,23 @@ func NewListener(
 }
 
 type Listener struct {
-	event         eventbus.EventDefinition
-	handling      func(interface{}) error
-	errorHandling func(interface{}, error)
+	event         domain.EventDefinition
+	handling      func(domain.Envelope) error
+	errorHandling func(domain.Envelope, error)
 }
 
 func (l *Listener) GetName() string {
 	return "projection"
 }
 
-func (l *Listener) GetEventDefinition() eventbus.EventDefinition {
+func (l *Listener) GetEventDefinition() domain.EventDefinition {
 	return l.event
 }
 
-func (l *Listener) ProcessEvent(event interface{}) error {
-	return l.handling(event)
+func (l *Listener) ProcessEvent(envelope domain.Envelope) error {
+	return l.handling(envelope)
 }
 
-func (l *Listener) HandleError(event interface{}, err error) {
-	l.errorHandling(event, err)
+func (l *Listener) HandleError(envelope domain.Envelope, err error) {
+	l.errorHandling(envelope, err)
 }
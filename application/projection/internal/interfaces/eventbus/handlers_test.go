@@ -11,6 +11,7 @@ import (
 	"github.com/frederic-gendebien/pact-poc/lib/eventbus"
 	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
 	inmemoryevb "github.com/frederic-gendebien/pact-poc/lib/eventbus/inmemory"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
 	"github.com/pact-foundation/pact-go/dsl"
 	"log"
 	"os"
@@ -119,14 +120,18 @@ func setup() {
 		DisableToolValidityCheck: true,
 	}
 	pact.Setup(false)
-	repo = inmemorypers.NewUserRepository()
-	useCase = usecase.NewUserProjectionUseCase(repo)
-	eventBus = inmemoryevb.NewEventBus()
+	logger := logging.NewDiscardLogger()
+	repo = inmemorypers.NewUserRepository(logger)
+	useCase = usecase.NewUserProjectionUseCase(repo, logger)
+	eventBus = inmemoryevb.NewEventBus(logger)
+	registry := NewRegistry(useCase, logger)
+	if err := registry.Validate(events.All()...); err != nil {
+		log.Fatalf("could not validate handlers: %v", err)
+	}
+
 	if err := eventBus.Listen(context.Background(),
-		ListenerName,
-		NewUserRegisteredHandler(useCase),
-		UserDetailsCorrectedHandler(useCase),
-		UserDeletedHandler(useCase),
+		registry.ListenerName(),
+		registry.Handlers()...,
 	); err != nil {
 		log.Fatalf("could not listen for events: %v", err)
 	}
@@ -3,6 +3,7 @@ package eventbus
 import (
 	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/events"
 	providermodel "github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
+	eventbus "github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
 )
 import "github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
 
@@ -21,3 +22,7 @@ func partialUserFrom(detailsCorrected *events.UserDetailsCorrected) model.User {
 		Email: "",
 	}
 }
+
+func checkpointOf(envelope eventbus.Envelope) model.Checkpoint {
+	return model.NewCheckpoint(envelope.Metadata.EventId, envelope.Metadata.Sequence)
+}
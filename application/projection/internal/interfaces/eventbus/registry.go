@@ -0,0 +1,155 @@
+package eventbus
+
+import (
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/usecase"
+	eventbus "github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"sort"
+	"strings"
+)
+
+func NewRegistry(useCase usecase.UserProjectionUseCase, logger logging.Logger) *Registry {
+	return NewHandlerRegistry(ListenerName).
+		Register(
+			NewUserRegisteredHandler(useCase, logger),
+			UserDetailsCorrectedHandler(useCase, logger),
+			UserDeletedHandler(useCase, logger),
+		)
+}
+
+func Handlers(logger logging.Logger) func(useCase usecase.UserProjectionUseCase) []eventbus.EventHandler {
+	return func(useCase usecase.UserProjectionUseCase) []eventbus.EventHandler {
+		return NewRegistry(useCase, logger).Handlers()
+	}
+}
+
+func NewHandlerRegistry(listenerName string) *Registry {
+	return &Registry{
+		listenerName: listenerName,
+		handlers:     make(map[string]eventbus.EventHandler),
+		ignored:      make(map[string]ignoredEvent),
+	}
+}
+
+type ignoredEvent struct {
+	event  eventbus.EventDefinition
+	reason string
+}
+
+type Registry struct {
+	listenerName string
+	handlers     map[string]eventbus.EventHandler
+	ignored      map[string]ignoredEvent
+	errors       []string
+}
+
+func (r *Registry) Register(handlers ...eventbus.EventHandler) *Registry {
+	for _, handler := range handlers {
+		key := eventKey(handler.GetEventDefinition())
+		if _, present := r.handlers[key]; present {
+			r.errors = append(r.errors, fmt.Sprintf("event %s has more than one handler", key))
+			continue
+		}
+
+		r.handlers[key] = handler
+	}
+
+	return r
+}
+
+func (r *Registry) Ignore(event eventbus.EventDefinition, reason string) *Registry {
+	r.ignored[eventKey(event)] = ignoredEvent{
+		event:  event,
+		reason: reason,
+	}
+
+	return r
+}
+
+func (r *Registry) Validate(events ...eventbus.EventDefinition) error {
+	problems := append([]string{}, r.errors...)
+	known := make(map[string]bool)
+	for _, event := range events {
+		key := eventKey(event)
+		known[key] = true
+		_, handled := r.handlers[key]
+		_, ignored := r.ignored[key]
+		switch {
+		case handled && ignored:
+			problems = append(problems, fmt.Sprintf("event %s is both handled and ignored", key))
+		case !handled && !ignored:
+			problems = append(problems, fmt.Sprintf("event %s has no handler and is not explicitly ignored", key))
+		}
+	}
+
+	for key := range r.handlers {
+		if !known[key] {
+			problems = append(problems, fmt.Sprintf("handler subscribes to unknown event %s", key))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid handlers for listener %s: %s", r.listenerName, strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+func (r *Registry) ListenerName() string {
+	return r.listenerName
+}
+
+func (r *Registry) Handlers() []eventbus.EventHandler {
+	handlers := make([]eventbus.EventHandler, 0, len(r.handlers))
+	for _, key := range sortedKeys(r.handlers) {
+		handlers = append(handlers, r.handlers[key])
+	}
+
+	return handlers
+}
+
+func (r *Registry) Subscriptions() model.ListenerSubscriptions {
+	subscriptions := make([]model.Subscription, 0, len(r.handlers)+len(r.ignored))
+	for _, handler := range r.Handlers() {
+		event := handler.GetEventDefinition()
+		subscriptions = append(subscriptions, model.Subscription{
+			Domain:  event.GetDomain(),
+			Name:    event.GetName(),
+			Handled: true,
+		})
+	}
+
+	for _, ignored := range r.ignored {
+		subscriptions = append(subscriptions, model.Subscription{
+			Domain: ignored.event.GetDomain(),
+			Name:   ignored.event.GetName(),
+			Reason: ignored.reason,
+		})
+	}
+
+	sort.Slice(subscriptions, func(a, b int) bool {
+		return subscriptions[a].Domain+"/"+subscriptions[a].Name < subscriptions[b].Domain+"/"+subscriptions[b].Name
+	})
+
+	return model.ListenerSubscriptions{
+		Listener:      r.listenerName,
+		Subscriptions: subscriptions,
+	}
+}
+
+func eventKey(event eventbus.EventDefinition) string {
+	return event.GetDomain() + "/" + event.GetName()
+}
+
+func sortedKeys(handlers map[string]eventbus.EventHandler) []string {
+	keys := make([]string, 0, len(handlers))
+	for key := range handlers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,89 @@
+package eventbus
+
+import (
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/events"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func handlerOf(event domain.EventDefinition) domain.EventHandler {
+	return NewListener(event, nil, nil)
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	unknown := domain.RawDefinition{Domain: events.Domain, Name: "UserRenamed"}
+	tests := []struct {
+		name     string
+		registry *Registry
+		problem  string
+	}{
+		{
+			name: "every event handled or ignored",
+			registry: NewHandlerRegistry("listener").
+				Register(handlerOf(events.NewUserRegistered{}), handlerOf(events.UserDetailsCorrected{})).
+				Ignore(events.UserDeleted{}, "deletions are not projected"),
+		},
+		{
+			name: "event without handler",
+			registry: NewHandlerRegistry("listener").
+				Register(handlerOf(events.NewUserRegistered{}), handlerOf(events.UserDetailsCorrected{})),
+			problem: "event user/UserDeleted has no handler and is not explicitly ignored",
+		},
+		{
+			name: "duplicate handler",
+			registry: NewHandlerRegistry("listener").
+				Register(handlerOf(events.NewUserRegistered{}), handlerOf(events.UserDetailsCorrected{}), handlerOf(events.UserDeleted{})).
+				Register(handlerOf(events.UserDeleted{})),
+			problem: "event user/UserDeleted has more than one handler",
+		},
+		{
+			name: "event both handled and ignored",
+			registry: NewHandlerRegistry("listener").
+				Register(handlerOf(events.NewUserRegistered{}), handlerOf(events.UserDetailsCorrected{}), handlerOf(events.UserDeleted{})).
+				Ignore(events.UserDeleted{}, "deletions are not projected"),
+			problem: "event user/UserDeleted is both handled and ignored",
+		},
+		{
+			name: "handler for an unknown event",
+			registry: NewHandlerRegistry("listener").
+				Register(handlerOf(events.NewUserRegistered{}), handlerOf(events.UserDetailsCorrected{}), handlerOf(events.UserDeleted{})).
+				Register(handlerOf(unknown)),
+			problem: "handler subscribes to unknown event user/UserRenamed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.registry.Validate(events.All()...)
+			switch {
+			case test.problem == "" && err != nil:
+				t.Fatalf("expected no error, but got: %v", err)
+			case test.problem != "" && (err == nil || !strings.Contains(err.Error(), test.problem)):
+				t.Fatalf("expected error containing %q, but got: %v", test.problem, err)
+			}
+		})
+	}
+}
+
+func TestRegistry_Subscriptions(t *testing.T) {
+	subscriptions := NewHandlerRegistry("listener").
+		Register(handlerOf(events.NewUserRegistered{}), handlerOf(events.UserDetailsCorrected{})).
+		Ignore(events.UserDeleted{}, "deletions are not projected").
+		Subscriptions()
+
+	expected := model.ListenerSubscriptions{
+		Listener: "listener",
+		Subscriptions: []model.Subscription{
+			{Domain: events.Domain, Name: "NewUserRegistered", Handled: true},
+			{Domain: events.Domain, Name: "UserDeleted", Reason: "deletions are not projected"},
+			{Domain: events.Domain, Name: "UserDetailsCorrected", Handled: true},
+		},
+	}
+
+	if !reflect.DeepEqual(subscriptions, expected) {
+		t.Fatalf("expected: %+v, but got: %+v", expected, subscriptions)
+	}
+}
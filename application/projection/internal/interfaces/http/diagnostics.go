@@ -0,0 +1,18 @@
+package http
+
+import (
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/gin-gonic/gin"
+	gohttp "net/http"
+)
+
+func addDiagnosticsHandlers(engine *gin.Engine, subscriptions model.ListenerSubscriptions) {
+	diagnostics := engine.Group("/diagnostics")
+	diagnostics.GET("subscriptions", getSubscriptions(subscriptions))
+}
+
+func getSubscriptions(subscriptions model.ListenerSubscriptions) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(gohttp.StatusOK, subscriptions)
+	}
+}
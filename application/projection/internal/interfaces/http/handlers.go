@@ -19,7 +19,7 @@ func findUsers(useCase usecase.UserProjectionUseCase) gin.HandlerFunc {
 			return
 		}
 
-		users, err := useCase.FindUsersByText(ctx, text)
+		users, err := useCase.FindUsersByText(ctx.Request.Context(), text)
 		okOrFail(ctx, err, func() interface{} {
 			return users
 		})
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/usecase"
+	"github.com/gin-gonic/gin"
+	gohttp "net/http"
+)
+
+func addIndexHandlers(engine *gin.Engine, useCase usecase.UserProjectionUseCase) {
+	index := engine.Group("/index")
+	index.GET("consistency", checkIndex(useCase))
+}
+
+func checkIndex(useCase usecase.UserProjectionUseCase) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		report, err := useCase.CheckIndex(ctx.Request.Context())
+		if err != nil {
+			fail(ctx, err)
+			return
+		}
+
+		status := gohttp.StatusOK
+		if !report.IsConsistent() {
+			status = gohttp.StatusConflict
+		}
+
+		ctx.JSON(status, report)
+	}
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/health"
+	"github.com/frederic-gendebien/pact-poc/lib/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	APIVersion = "1.0.0"
+)
+
+func addOpenAPIHandlers(engine *gin.Engine) {
+	engine.GET("/openapi.json", openapi.Handler(Specification()))
+}
+
+// Specification documents every route registered by NewServer, the request
+// and response schemas being generated from the model types.
+func Specification() *openapi.Document {
+	document := openapi.NewDocument(ServiceName, APIVersion)
+	badRequest := document.JSONResponse("invalid request", ErrorResponse{})
+	failure := document.JSONResponse("unexpected failure", ErrorResponse{})
+
+	return document.
+		Add("GET", "/users", openapi.Operation{
+			OperationId: "findUsers",
+			Summary:     "find the users matching a text",
+			Tags:        []string{"users"},
+			Parameters: []openapi.Parameter{
+				openapi.QueryParameter("text", &openapi.Schema{Type: openapi.TypeString}, true, "text searched in the names and emails"),
+			},
+			Responses: map[string]openapi.Response{
+				"200": document.JSONResponse("the matching users", []model.User{}),
+				"400": badRequest,
+				"500": failure,
+			},
+		}).
+		Add("GET", "/index/consistency", openapi.Operation{
+			OperationId: "checkIndex",
+			Summary:     "check the consistency of the search index",
+			Tags:        []string{"operations"},
+			Responses: map[string]openapi.Response{
+				"200": document.JSONResponse("the index is consistent", model.IndexReport{}),
+				"409": document.JSONResponse("the index has orphans", model.IndexReport{}),
+				"500": failure,
+			},
+		}).
+		Add("POST", "/projection/rebuild", openapi.Operation{
+			OperationId: "startRebuild",
+			Summary:     "rebuild the projection from the event archive",
+			Tags:        []string{"operations"},
+			Responses: map[string]openapi.Response{
+				"202": document.JSONResponse("rebuild started", model.RebuildStatus{}),
+				"400": badRequest,
+				"500": failure,
+			},
+		}).
+		Add("GET", "/projection/rebuild", openapi.Operation{
+			OperationId: "getRebuildStatus",
+			Summary:     "get the status of the last rebuild",
+			Tags:        []string{"operations"},
+			Responses: map[string]openapi.Response{
+				"200": document.JSONResponse("the rebuild status", model.RebuildStatus{}),
+			},
+		}).
+		Add("GET", "/diagnostics/subscriptions", openapi.Operation{
+			OperationId: "getSubscriptions",
+			Summary:     "list the event subscriptions of the listener",
+			Tags:        []string{"operations"},
+			Responses: map[string]openapi.Response{
+				"200": document.JSONResponse("the subscriptions", model.ListenerSubscriptions{}),
+			},
+		}).
+		Add("GET", "/health/live", openapi.Operation{
+			OperationId: "getLiveness",
+			Tags:        []string{"operations"},
+			Responses: map[string]openapi.Response{
+				"200": document.JSONResponse("the service is live", health.Report{}),
+				"503": document.JSONResponse("the service is not live", health.Report{}),
+			},
+		}).
+		Add("GET", "/health/ready", openapi.Operation{
+			OperationId: "getReadiness",
+			Tags:        []string{"operations"},
+			Responses: map[string]openapi.Response{
+				"200": document.JSONResponse("the service is ready", health.Report{}),
+				"503": document.JSONResponse("the service is not ready", health.Report{}),
+			},
+		}).
+		Add("GET", "/metrics", openapi.Operation{
+			OperationId: "getMetrics",
+			Summary:     "prometheus metrics",
+			Tags:        []string{"operations"},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "the metrics", Content: openapi.Text()},
+			},
+		}).
+		Add("GET", "/openapi.json", openapi.Operation{
+			OperationId: "getSpecification",
+			Summary:     "this document",
+			Tags:        []string{"operations"},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "the OpenAPI document", Content: map[string]openapi.MediaType{
+					openapi.ContentTypeJSON: {Schema: &openapi.Schema{Type: openapi.TypeObject}},
+				}},
+			},
+		})
+}
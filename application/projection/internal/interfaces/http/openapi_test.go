@@ -0,0 +1,51 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/config/environment"
+	"github.com/frederic-gendebien/pact-poc/lib/health"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/openapi"
+	"github.com/gin-gonic/gin"
+	gohttp "net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	configuration := environment.NewConfiguration()
+	logger := logging.NewLogger(configuration)
+	server := NewServer(configuration, nil, nil, model.ListenerSubscriptions{}, health.NewHealth(configuration, logger), logger)
+
+	return server.server.Handler.(*gin.Engine)
+}
+
+func TestSpecificationCoversRoutes(t *testing.T) {
+	if undocumented := openapi.Undocumented(newEngine(), Specification()); len(undocumented) > 0 {
+		t.Fatalf("expected every route to be in the specification, but missing: %+v", undocumented)
+	}
+}
+
+func TestSpecificationIsServed(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newEngine().ServeHTTP(recorder, httptest.NewRequest(gohttp.MethodGet, "/openapi.json", nil))
+	if recorder.Code != gohttp.StatusOK {
+		t.Fatalf("expected status %d, but got: %d", gohttp.StatusOK, recorder.Code)
+	}
+
+	document := openapi.Document{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+		t.Fatalf("could not decode specification: %v", err)
+	}
+
+	user := document.Components.Schemas["User"]
+	if user == nil || len(user.Properties) != 3 || user.Properties["email"] == nil {
+		t.Fatalf("expected the User schema to be generated from the model, but got: %+v", user)
+	}
+
+	if _, found := document.Components.Schemas["ErrorResponse"]; !found {
+		t.Fatalf("expected the ErrorResponse schema, but got: %v", document.Components.Schemas)
+	}
+}
@@ -0,0 +1,30 @@
+package http
+
+import (
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/usecase"
+	"github.com/gin-gonic/gin"
+	gohttp "net/http"
+)
+
+func addRebuildHandlers(engine *gin.Engine, useCase usecase.RebuildUseCase) {
+	rebuild := engine.Group("/projection/rebuild")
+	rebuild.POST("", startRebuild(useCase))
+	rebuild.GET("", getRebuildStatus(useCase))
+}
+
+func startRebuild(useCase usecase.RebuildUseCase) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := useCase.StartRebuild(ctx); err != nil {
+			fail(ctx, err)
+			return
+		}
+
+		ctx.JSON(gohttp.StatusAccepted, useCase.RebuildStatus(ctx))
+	}
+}
+
+func getRebuildStatus(useCase usecase.RebuildUseCase) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(gohttp.StatusOK, useCase.RebuildStatus(ctx))
+	}
+}
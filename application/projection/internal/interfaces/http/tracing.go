@@ -0,0 +1,14 @@
+package http
+
+import (
+	"github.com/frederic-gendebien/pact-poc/lib/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ServiceName = "projection"
+)
+
+func addTracingMiddleware(engine *gin.Engine) {
+	engine.Use(tracing.GinMiddleware(ServiceName))
+}
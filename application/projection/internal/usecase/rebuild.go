@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/repository"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/events"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/archive"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"sync"
+	"time"
+)
+
+type RebuildUseCase interface {
+	StartRebuild(ctx context.Context) error
+	RebuildStatus(ctx context.Context) model.RebuildStatus
+}
+
+func NewRebuildUseCase(
+	eventArchive archive.Archive,
+	repository repository.SwappableUserRepository,
+	newRepository func() (repository.UserRepository, error),
+	handlers func(useCase UserProjectionUseCase) []domain.EventHandler,
+	logger logging.Logger,
+) *DefaultRebuildUseCase {
+	return &DefaultRebuildUseCase{
+		logger:        logger,
+		archive:       eventArchive,
+		repository:    repository,
+		newRepository: newRepository,
+		handlers:      handlers,
+		lock:          &sync.RWMutex{},
+		status:        model.RebuildStatus{State: model.RebuildIdle},
+	}
+}
+
+type DefaultRebuildUseCase struct {
+	logger        logging.Logger
+	archive       archive.Archive
+	repository    repository.SwappableUserRepository
+	newRepository func() (repository.UserRepository, error)
+	handlers      func(useCase UserProjectionUseCase) []domain.EventHandler
+	lock          *sync.RWMutex
+	status        model.RebuildStatus
+}
+
+func (d *DefaultRebuildUseCase) StartRebuild(ctx context.Context) error {
+	if !archive.IsEnabled(d.archive) {
+		return model.NewBadRequest(archive.ErrDisabled.Error())
+	}
+
+	total, err := d.archive.Count(ctx, events.Domain)
+	if err != nil {
+		return model.NewUnknownError("could not count archived events", err)
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.status.IsRunning() {
+		return model.NewBadRequest("a projection rebuild is already running")
+	}
+
+	startedAt := time.Now()
+	d.status = model.RebuildStatus{
+		State:     model.RebuildRunning,
+		Total:     total,
+		StartedAt: &startedAt,
+	}
+
+	go d.rebuild(context.Background())
+
+	return nil
+}
+
+func (d *DefaultRebuildUseCase) RebuildStatus(ctx context.Context) model.RebuildStatus {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return d.status
+}
+
+func (d *DefaultRebuildUseCase) rebuild(ctx context.Context) {
+	d.logger.Infof("rebuilding projection from the event archive")
+	next, err := d.newRepository()
+	if err != nil {
+		d.logger.Errorf("could not create projection repository: %v", err)
+		d.finish(err)
+		return
+	}
+
+	apply := d.applyTo(next)
+
+	position, err := d.archive.Replay(ctx, events.Domain, 0, apply)
+	if err == nil {
+		err = d.repository.Swap(func(current repository.UserRepository) (repository.UserRepository, error) {
+			if _, err := d.archive.Replay(ctx, events.Domain, position, apply); err != nil {
+				return nil, err
+			}
+
+			if err := d.checkNotEmptied(ctx, current); err != nil {
+				return nil, err
+			}
+
+			return next, nil
+		})
+	}
+
+	if err != nil {
+		d.logger.Errorf("could not rebuild projection: %v", err)
+		_ = next.Close()
+	}
+
+	d.finish(err)
+}
+
+// checkNotEmptied refuses to replace a populated projection when the archive
+// replayed nothing, which means the archive is missing rather than the users.
+func (d *DefaultRebuildUseCase) checkNotEmptied(ctx context.Context, current repository.UserRepository) error {
+	if d.RebuildStatus(ctx).Replayed > 0 {
+		return nil
+	}
+
+	report, err := current.CheckIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	if report.Users > 0 {
+		return fmt.Errorf("no event was replayed but the projection has %d users, keeping it", report.Users)
+	}
+
+	return nil
+}
+
+func (d *DefaultRebuildUseCase) applyTo(next repository.UserRepository) func(envelope domain.Envelope) error {
+	handlers := make(map[string]domain.EventHandler)
+	for _, handler := range d.handlers(NewUserProjectionUseCase(next, d.logger)) {
+		handlers[handler.GetEventDefinition().GetName()] = handler
+	}
+
+	return func(envelope domain.Envelope) error {
+		handler, present := handlers[envelope.Metadata.Name]
+		if !present {
+			d.progress(func(status *model.RebuildStatus) {
+				status.Skipped++
+			})
+			return nil
+		}
+
+		decoded, err := domain.DecodeEnvelope(envelope, handler.GetEventDefinition())
+		if err == nil {
+			err = handler.ProcessEvent(decoded)
+		}
+
+		if err != nil {
+			handler.HandleError(decoded, err)
+		}
+
+		d.progress(func(status *model.RebuildStatus) {
+			status.Replayed++
+			if err != nil {
+				status.Failures++
+			}
+		})
+
+		return nil
+	}
+}
+
+func (d *DefaultRebuildUseCase) progress(update func(status *model.RebuildStatus)) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	update(&d.status)
+}
+
+func (d *DefaultRebuildUseCase) finish(err error) {
+	d.progress(func(status *model.RebuildStatus) {
+		finishedAt := time.Now()
+		status.FinishedAt = &finishedAt
+		status.State = model.RebuildCompleted
+		if err != nil {
+			status.State = model.RebuildFailed
+			status.LastError = err.Error()
+		}
+	})
+}
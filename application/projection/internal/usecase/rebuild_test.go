@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/repository"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/infrastructure/persistence"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/infrastructure/persistence/inmemory"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"testing"
+	"time"
+)
+
+type emptyArchive struct {
+}
+
+func (e emptyArchive) Close() error {
+	return nil
+}
+
+func (e emptyArchive) Append(ctx context.Context, envelope domain.Envelope) error {
+	return nil
+}
+
+func (e emptyArchive) Count(ctx context.Context, domainName string) (int, error) {
+	return 0, nil
+}
+
+func (e emptyArchive) Replay(ctx context.Context, domainName string, from int, handle func(envelope domain.Envelope) error) (int, error) {
+	return from, nil
+}
+
+func rebuildFromEmptyArchive(t *testing.T, live repository.UserRepository) (model.RebuildStatus, repository.UserRepository) {
+	logger := logging.NewDiscardLogger()
+	swappable := persistence.NewSwappableUserRepository(live, logger)
+	rebuild := NewRebuildUseCase(emptyArchive{}, swappable, func() (repository.UserRepository, error) {
+		return inmemory.NewUserRepository(logger), nil
+	}, func(useCase UserProjectionUseCase) []domain.EventHandler {
+		return nil
+	}, logger)
+
+	if err := rebuild.StartRebuild(context.Background()); err != nil {
+		t.Fatalf("could not start rebuild: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for rebuild.RebuildStatus(context.Background()).IsRunning() {
+		if time.Now().After(deadline) {
+			t.Fatalf("rebuild did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return rebuild.RebuildStatus(context.Background()), swappable
+}
+
+func TestRebuild_KeepsPopulatedProjectionWhenNothingIsReplayed(t *testing.T) {
+	ctx := context.Background()
+	live := inmemory.NewUserRepository(logging.NewDiscardLogger())
+	user := model.User{Id: "user1", Name: "Jane Doe", Email: "jane@doe.com"}
+	if err := live.IndexUser(ctx, user, model.NewCheckpoint("event1", 1)); err != nil {
+		t.Fatalf("could not index user: %v", err)
+	}
+
+	status, current := rebuildFromEmptyArchive(t, live)
+	if status.State != model.RebuildFailed || status.LastError == "" {
+		t.Fatalf("expected the rebuild to be refused, but got: %+v", status)
+	}
+
+	if users, err := current.FindUsersByText(ctx, "jane"); err != nil || len(users) != 1 {
+		t.Fatalf("expected the live projection to be kept, but got: %v, %v", users, err)
+	}
+}
+
+func TestRebuild_SwapsEmptyProjection(t *testing.T) {
+	status, _ := rebuildFromEmptyArchive(t, inmemory.NewUserRepository(logging.NewDiscardLogger()))
+	if status.State != model.RebuildCompleted {
+		t.Fatalf("expected the rebuild to complete, but got: %+v", status)
+	}
+}
@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/tracing"
+)
+
+func NewTracedUserProjectionUseCase(useCase UserProjectionUseCase) *TracedUserProjectionUseCase {
+	return &TracedUserProjectionUseCase{
+		useCase: useCase,
+	}
+}
+
+type TracedUserProjectionUseCase struct {
+	useCase UserProjectionUseCase
+}
+
+func (t *TracedUserProjectionUseCase) IndexUser(ctx context.Context, user model.User, checkpoint model.Checkpoint) (err error) {
+	ctx, span := tracing.Start(ctx, "usecase IndexUser")
+	defer func() { tracing.End(span, err) }()
+
+	return t.useCase.IndexUser(ctx, user, checkpoint)
+}
+
+func (t *TracedUserProjectionUseCase) DeleteUserById(ctx context.Context, userId model.UserId, checkpoint model.Checkpoint) (err error) {
+	ctx, span := tracing.Start(ctx, "usecase DeleteUserById")
+	defer func() { tracing.End(span, err) }()
+
+	return t.useCase.DeleteUserById(ctx, userId, checkpoint)
+}
+
+func (t *TracedUserProjectionUseCase) FindUsersByText(ctx context.Context, text string) (users []model.User, err error) {
+	ctx, span := tracing.Start(ctx, "usecase FindUsersByText")
+	defer func() { tracing.End(span, err) }()
+
+	return t.useCase.FindUsersByText(ctx, text)
+}
+
+func (t *TracedUserProjectionUseCase) CheckIndex(ctx context.Context) (report model.IndexReport, err error) {
+	ctx, span := tracing.Start(ctx, "usecase CheckIndex")
+	defer func() { tracing.End(span, err) }()
+
+	return t.useCase.CheckIndex(ctx)
+}
@@ -2,34 +2,52 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/model"
 	"github.com/frederic-gendebien/pact-poc/application/projection/internal/domain/repository"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
 )
 
 type UserProjectionUseCase interface {
-	IndexUser(ctx context.Context, user model.User) error
-	DeleteUserById(ctx context.Context, userId model.UserId) error
+	IndexUser(ctx context.Context, user model.User, checkpoint model.Checkpoint) error
+	DeleteUserById(ctx context.Context, userId model.UserId, checkpoint model.Checkpoint) error
 	FindUsersByText(ctx context.Context, text string) ([]model.User, error)
+	CheckIndex(ctx context.Context) (model.IndexReport, error)
 }
 
-func NewUserProjectionUseCase(repository repository.UserRepository) *DefaultUserProjectionUseCase {
+func NewUserProjectionUseCase(repository repository.UserRepository, logger logging.Logger) *DefaultUserProjectionUseCase {
 	return &DefaultUserProjectionUseCase{
 		repository: repository,
+		logger:     logger,
 	}
 }
 
 type DefaultUserProjectionUseCase struct {
 	repository repository.UserRepository
+	logger     logging.Logger
 }
 
-func (d *DefaultUserProjectionUseCase) IndexUser(ctx context.Context, user model.User) error {
-	return d.repository.IndexUser(ctx, user)
+func (d *DefaultUserProjectionUseCase) IndexUser(ctx context.Context, user model.User, checkpoint model.Checkpoint) error {
+	return d.skipped(ctx, user.Id, d.repository.IndexUser(ctx, user, checkpoint))
 }
 
-func (d *DefaultUserProjectionUseCase) DeleteUserById(ctx context.Context, userId model.UserId) error {
-	return d.repository.DeleteUserById(ctx, userId)
+func (d *DefaultUserProjectionUseCase) DeleteUserById(ctx context.Context, userId model.UserId, checkpoint model.Checkpoint) error {
+	return d.skipped(ctx, userId, d.repository.DeleteUserById(ctx, userId, checkpoint))
 }
 
 func (d *DefaultUserProjectionUseCase) FindUsersByText(ctx context.Context, text string) ([]model.User, error) {
 	return d.repository.FindUsersByText(ctx, text)
 }
+
+func (d *DefaultUserProjectionUseCase) CheckIndex(ctx context.Context) (model.IndexReport, error) {
+	return d.repository.CheckIndex(ctx)
+}
+
+func (d *DefaultUserProjectionUseCase) skipped(ctx context.Context, userId model.UserId, err error) error {
+	if errors.Is(err, model.SkippedEventError{}) {
+		d.logger.WithContext(ctx).With(logging.UserId, userId).Infof("%v", err)
+		return nil
+	}
+
+	return err
+}
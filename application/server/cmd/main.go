@@ -6,36 +6,60 @@ import (
 	"github.com/frederic-gendebien/pact-poc/application/server/internal/interfaces/http"
 	"github.com/frederic-gendebien/pact-poc/application/server/internal/usecase"
 	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/frederic-gendebien/pact-poc/lib/config/reload"
 	"github.com/frederic-gendebien/pact-poc/lib/eventbus"
+	"github.com/frederic-gendebien/pact-poc/lib/health"
+	"github.com/frederic-gendebien/pact-poc/lib/lifecycle"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/outbox"
+	"github.com/frederic-gendebien/pact-poc/lib/tracing"
 	"log"
+	"os"
 )
 
 var (
 	configuration config.Configuration
+	logger        logging.Logger
+	tracer        *tracing.Provider
 	repo          repository.UserRepository
 	eventBus      eventbus.EventBus
+	relay         *outbox.Relay
 	useCase       usecase.UserUseCase
 	server        *http.Server
 )
 
 func init() {
 	configuration = config.NewConfiguration()
-	repo = persistence.NewUserRepository(configuration)
-	eventBus = eventbus.NewEventBus(configuration)
-	useCase = usecase.NewUserUseCase(repo, eventBus)
-	server = http.NewServer(useCase)
+	if err := config.Validate(configuration); err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	logger = logging.NewLogger(configuration).With(logging.Service, http.ServiceName)
+	tracer = tracing.NewProvider(configuration, logger, http.ServiceName)
+	repo = persistence.NewInstrumentedUserRepository(persistence.NewUserRepository(configuration, logger))
+	eventBus = eventbus.NewEventBus(configuration, logger)
+	relay = outbox.NewRelay(configuration, repo, eventBus, logger)
+	useCase = usecase.NewTracedUserUseCase(usecase.NewUserUseCase(repo, relay, logger))
+	server = http.NewServer(configuration, useCase, relay, health.NewHealth(configuration, logger).
+		Add("repository", repo).
+		Add("eventbus", eventBus), logger)
 }
 
 func main() {
-	defer teardown()
+	manager := lifecycle.NewManager(configuration, logger).
+		Add("configuration", lifecycle.Closer(configuration)).
+		Add("tracer", lifecycle.NewComponent(nil, tracer.Shutdown)).
+		Add("configuration watcher", lifecycle.Routine(reload.NewWatcher(configuration, logger).Run)).
+		Add("repository", lifecycle.Closer(repo)).
+		Add("eventbus", lifecycle.Closer(eventBus)).
+		Add("outbox relay", lifecycle.NewComponent(relay.Run, relay.Flush)).
+		Add("http server", server)
 
-	log.Println("starting server...")
-	log.Fatalln(server.Start())
-}
+	if err := config.PrintEffectiveIfRequested(configuration, os.Stdout); err != nil {
+		logger.Warnf("could not print effective configuration: %v", err)
+	}
 
-func teardown() {
-	log.Println("tearing down server resources")
-	_ = repo.Close()
-	_ = eventBus.Close()
-	_ = configuration.Close()
+	logger.Infof("starting server...")
+	if err := manager.Run(); err != nil {
+		logger.Fatalf("%v", err)
+	}
 }
@@ -3,14 +3,24 @@ package repository
 import (
 	"context"
 	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/health"
+	"github.com/frederic-gendebien/pact-poc/lib/outbox"
 	"io"
 )
 
 type UserRepository interface {
 	io.Closer
-	AddUser(ctx context.Context, newUser model.User) error
-	UpdateUser(ctx context.Context, userId model.UserId, update func(user model.User) model.User) error
-	DeleteUser(ctx context.Context, userId model.UserId) error
-	ListAllUsers(ctx context.Context, next <-chan bool) (<-chan model.User, error)
+	health.Checker
+	outbox.Store
+	AddUser(ctx context.Context, newUser model.User, pending ...domain.Envelope) error
+	UpdateUser(ctx context.Context, userId model.UserId, update func(user model.User) model.User, pending ...domain.Envelope) error
+	DeleteUser(ctx context.Context, userId model.UserId, pending ...domain.Envelope) error
+	ListUsers(ctx context.Context, after model.UserId, limit int) ([]model.User, error)
+	ListAllUsers(ctx context.Context) model.UserIterator
 	GetUser(ctx context.Context, userId model.UserId) (model.User, error)
 }
+
+type UserHistoryRepository interface {
+	GetUserHistory(ctx context.Context, userId model.UserId) ([]model.UserHistoryEntry, error)
+}
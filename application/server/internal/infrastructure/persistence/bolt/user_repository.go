@@ -0,0 +1,232 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	boltoutbox "github.com/frederic-gendebien/pact-poc/lib/outbox/bolt"
+	"go.etcd.io/bbolt"
+	"time"
+)
+
+var (
+	usersBucket  = []byte("users")
+	emailsBucket = []byte("emails")
+)
+
+const (
+	listBatchSize = 50
+)
+
+func NewUserRepository(path string, logger logging.Logger) (*UserRepository, error) {
+	logger.Infof("starting bolt user repository: %s", path)
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("could not open bolt database %s: %v", path, err)
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{usersBucket, emailsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("could not initialize bolt database %s: %v", path, err)
+	}
+
+	store, err := boltoutbox.NewStore(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &UserRepository{
+		Store:  store,
+		logger: logger,
+		db:     db,
+	}, nil
+}
+
+type UserRepository struct {
+	*boltoutbox.Store
+	logger logging.Logger
+	db     *bbolt.DB
+}
+
+func (r *UserRepository) Close() error {
+	r.logger.Infof("closing bolt user repository")
+
+	return r.db.Close()
+}
+
+func (r *UserRepository) CheckHealth(ctx context.Context) error {
+	return r.db.View(func(tx *bbolt.Tx) error {
+		return nil
+	})
+}
+
+func (r *UserRepository) Clear(ctx context.Context) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{usersBucket, emailsBucket} {
+			if err := tx.DeleteBucket(bucket); err != nil {
+				return err
+			}
+
+			if _, err := tx.CreateBucket(bucket); err != nil {
+				return err
+			}
+		}
+
+		return r.Store.Clear(tx)
+	})
+}
+
+func (r *UserRepository) AddUser(ctx context.Context, newUser model.User, pending ...domain.Envelope) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		email := newUser.Email
+		if tx.Bucket(emailsBucket).Get([]byte(email)) != nil {
+			return model.NewBadRequest(fmt.Sprintf("user email : %s already exists", email))
+		}
+
+		userId := newUser.Id
+		if tx.Bucket(usersBucket).Get([]byte(userId)) != nil {
+			return model.NewBadRequest(fmt.Sprintf("user with id: %s already exists", userId))
+		}
+
+		if err := putUser(tx, newUser); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket(emailsBucket).Put([]byte(email), []byte(userId)); err != nil {
+			return err
+		}
+
+		return r.Store.Add(tx, pending...)
+	})
+}
+
+func (r *UserRepository) UpdateUser(ctx context.Context, userId model.UserId, update func(user model.User) model.User, pending ...domain.Envelope) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		user, err := getUser(tx, userId)
+		if err != nil {
+			return err
+		}
+
+		updatedUser := update(user)
+		updatedUser.Id = userId
+		if updatedUser.Email != user.Email {
+			emails := tx.Bucket(emailsBucket)
+			if emails.Get([]byte(updatedUser.Email)) != nil {
+				return model.NewBadRequest(fmt.Sprintf("user email : %s already exists", updatedUser.Email))
+			}
+
+			if err := emails.Delete([]byte(user.Email)); err != nil {
+				return err
+			}
+
+			if err := emails.Put([]byte(updatedUser.Email), []byte(userId)); err != nil {
+				return err
+			}
+		}
+
+		if err := putUser(tx, updatedUser); err != nil {
+			return err
+		}
+
+		return r.Store.Add(tx, pending...)
+	})
+}
+
+func (r *UserRepository) DeleteUser(ctx context.Context, userId model.UserId, pending ...domain.Envelope) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		user, err := getUser(tx, userId)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Bucket(usersBucket).Delete([]byte(userId)); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket(emailsBucket).Delete([]byte(user.Email)); err != nil {
+			return err
+		}
+
+		return r.Store.Add(tx, pending...)
+	})
+}
+
+func (r *UserRepository) ListUsers(ctx context.Context, after model.UserId, limit int) ([]model.User, error) {
+	users := make([]model.User, 0, limit)
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		cursor := tx.Bucket(usersBucket).Cursor()
+		key, value := cursor.First()
+		if after != "" {
+			key, value = cursor.Seek([]byte(after))
+			if key != nil && string(key) == string(after) {
+				key, value = cursor.Next()
+			}
+		}
+
+		for ; key != nil && len(users) < limit; key, value = cursor.Next() {
+			user := model.User{}
+			if err := json.Unmarshal(value, &user); err != nil {
+				return err
+			}
+			users = append(users, user)
+		}
+
+		return nil
+	})
+
+	return users, err
+}
+
+func (r *UserRepository) ListAllUsers(ctx context.Context) model.UserIterator {
+	return model.NewUserIterator(ctx, model.PagedFetcher(listBatchSize, r.ListUsers))
+}
+
+func (r *UserRepository) GetUser(ctx context.Context, userId model.UserId) (model.User, error) {
+	var user model.User
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		user, err = getUser(tx, userId)
+		return err
+	})
+
+	return user, err
+}
+
+func getUser(tx *bbolt.Tx, userId model.UserId) (model.User, error) {
+	value := tx.Bucket(usersBucket).Get([]byte(userId))
+	if value == nil {
+		return model.User{}, notFound(userId)
+	}
+
+	user := model.User{}
+	if err := json.Unmarshal(value, &user); err != nil {
+		return model.User{}, model.NewUnknownError(fmt.Sprintf("could not read user with id: %s", userId), err)
+	}
+
+	return user, nil
+}
+
+func putUser(tx *bbolt.Tx, user model.User) error {
+	value, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(usersBucket).Put([]byte(user.Id), value)
+}
+
+func notFound(userId model.UserId) model.NotFoundError {
+	return model.NewNotFoundError(fmt.Sprintf("user with id: %s was not found", userId))
+}
@@ -0,0 +1,179 @@
+package bolt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/events"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUserRepository(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "users.db")
+	repository := newTestRepository(t, path)
+
+	for _, number := range []int{3, 1, 2} {
+		if err := repository.AddUser(ctx, testUser(number), domain.NewEnvelope(ctx, events.NewUserRegistered{User: testUser(number)})); err != nil {
+			t.Fatalf("could not add user: %v", err)
+		}
+	}
+
+	duplicate := testUser(4)
+	duplicate.Email = testUser(1).Email
+	if err := repository.AddUser(ctx, duplicate); !errors.Is(err, model.BadRequestError{}) {
+		t.Fatalf("a %v was expected, but found: %v", model.BadRequestError{}, err)
+	}
+
+	if err := repository.UpdateUser(ctx, testUser(2).Id, func(user model.User) model.User {
+		return user.CorrectDetails(model.UserDetails{Name: "corrected"})
+	}); err != nil {
+		t.Fatalf("could not update user: %v", err)
+	}
+
+	if err := repository.Close(); err != nil {
+		t.Fatalf("could not close repository: %v", err)
+	}
+
+	repository = newTestRepository(t, path)
+	defer repository.Close()
+
+	user, err := repository.GetUser(ctx, testUser(2).Id)
+	if err != nil || user.Details.Name != "corrected" {
+		t.Fatalf("expected corrected user after reopening, but got: %v, %v", user, err)
+	}
+
+	users, err := repository.ListUsers(ctx, "", 10)
+	if err != nil {
+		t.Fatalf("could not list users: %v", err)
+	}
+
+	ids := make([]model.UserId, 0, 3)
+	for _, user := range users {
+		ids = append(ids, user.Id)
+	}
+
+	if expected := []model.UserId{"user1", "user2", "user3"}; !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected: %v, but got %v", expected, ids)
+	}
+
+	if users, err := repository.ListUsers(ctx, "user1", 1); err != nil || len(users) != 1 || users[0].Id != "user2" {
+		t.Fatalf("expected user2 after user1, but got: %v, %v", users, err)
+	}
+
+	pending, err := repository.PendingEvents(ctx, 10)
+	if err != nil || len(pending) != 3 {
+		t.Fatalf("expected 3 pending events, but got: %d, %v", len(pending), err)
+	}
+
+	if pending[0].Envelope.Metadata.EntityId != "user3" {
+		t.Fatalf("expected pending events in insertion order, but got: %v", pending[0].Envelope.Metadata)
+	}
+
+	if err := repository.MarkPublished(ctx, pending[0].Id); err != nil {
+		t.Fatalf("could not mark event as published: %v", err)
+	}
+
+	if count, err := repository.CountPendingEvents(ctx); err != nil || count != 2 {
+		t.Fatalf("expected 2 pending events, but got: %d, %v", count, err)
+	}
+}
+
+func TestUserRepository_Sequences(t *testing.T) {
+	ctx := context.Background()
+	repository := newTestRepository(t, filepath.Join(t.TempDir(), "users.db"))
+	defer repository.Close()
+
+	user := testUser(1)
+	if err := repository.AddUser(ctx, user, domain.NewEnvelope(ctx, events.NewUserRegistered{User: user})); err != nil {
+		t.Fatalf("could not add user: %v", err)
+	}
+
+	published, err := repository.PendingEvents(ctx, 10)
+	if err != nil || len(published) != 1 {
+		t.Fatalf("expected 1 pending event, but got: %v, %v", published, err)
+	}
+
+	if err := repository.MarkPublished(ctx, published[0].Id); err != nil {
+		t.Fatalf("could not mark event as published: %v", err)
+	}
+
+	if err := repository.DeleteUser(ctx, user.Id, domain.NewEnvelope(ctx, events.UserDeleted{UserId: user.Id})); err != nil {
+		t.Fatalf("could not delete user: %v", err)
+	}
+
+	if err := repository.AddUser(ctx, user, domain.NewEnvelope(ctx, events.NewUserRegistered{User: user})); err != nil {
+		t.Fatalf("could not add user again: %v", err)
+	}
+
+	pending, err := repository.PendingEvents(ctx, 10)
+	if err != nil || len(pending) != 2 {
+		t.Fatalf("expected 2 pending events, but got: %v, %v", pending, err)
+	}
+
+	if published[0].Envelope.Metadata.Sequence != 1 || pending[0].Envelope.Metadata.Sequence != 2 || pending[1].Envelope.Metadata.Sequence != 3 {
+		t.Fatalf("expected events of the user to be sequenced, but got: %v, %v", published, pending)
+	}
+}
+
+func TestUserRepository_ListAllUsers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repository := newTestRepository(t, filepath.Join(t.TempDir(), "users.db"))
+	defer repository.Close()
+
+	for number := 1; number <= 3; number++ {
+		if err := repository.AddUser(ctx, testUser(number)); err != nil {
+			t.Fatalf("could not add user: %v", err)
+		}
+	}
+
+	users := model.NewUserIterator(ctx, model.PagedFetcher(2, repository.ListUsers))
+	defer users.Close()
+
+	ids := make([]model.UserId, 0, 3)
+	for users.Next() {
+		ids = append(ids, users.User().Id)
+	}
+
+	if expected := []model.UserId{"user1", "user2", "user3"}; users.Err() != nil || !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected: %v, but got %v, %v", expected, ids, users.Err())
+	}
+
+	cancelled := repository.ListAllUsers(ctx)
+	defer cancelled.Close()
+
+	if !cancelled.Next() {
+		t.Fatalf("expected a first user, but got: %v", cancelled.Err())
+	}
+
+	cancel()
+	if cancelled.Next() || !errors.Is(cancelled.Err(), context.Canceled) {
+		t.Fatalf("expected iteration to stop on cancellation, but got: %v", cancelled.Err())
+	}
+}
+
+func newTestRepository(t *testing.T, path string) *UserRepository {
+	repository, err := NewUserRepository(path, logging.NewDiscardLogger())
+	if err != nil {
+		t.Fatalf("could not open repository: %v", err)
+	}
+
+	return repository
+}
+
+func testUser(number int) model.User {
+	return model.User{
+		Id: model.UserId(fmt.Sprintf("user%d", number)),
+		Details: model.UserDetails{
+			Name: fmt.Sprintf("name%d", number),
+		},
+		Email: model.Email(fmt.Sprintf("email%d", number)),
+	}
+}
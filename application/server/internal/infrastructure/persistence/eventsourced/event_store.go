@@ -0,0 +1,246 @@
+package eventsourced
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	boltoutbox "github.com/frederic-gendebien/pact-poc/lib/outbox/bolt"
+	"go.etcd.io/bbolt"
+	"time"
+)
+
+var (
+	streamsBucket   = []byte("streams")
+	snapshotsBucket = []byte("snapshots")
+)
+
+type StoredEvent struct {
+	StreamId   string          `json:"stream_id"`
+	Version    int             `json:"version"`
+	Envelope   domain.Envelope `json:"envelope"`
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+type Snapshot struct {
+	StreamId string          `json:"stream_id"`
+	Version  int             `json:"version"`
+	State    json.RawMessage `json:"state"`
+	TakenAt  time.Time       `json:"taken_at"`
+}
+
+func NewConcurrencyError(streamId string, expectedVersion int, actualVersion int) ConcurrencyError {
+	return ConcurrencyError{
+		StreamId:        streamId,
+		ExpectedVersion: expectedVersion,
+		ActualVersion:   actualVersion,
+	}
+}
+
+type ConcurrencyError struct {
+	StreamId        string `json:"stream_id"`
+	ExpectedVersion int    `json:"expected_version"`
+	ActualVersion   int    `json:"actual_version"`
+}
+
+func (c ConcurrencyError) Error() string {
+	return fmt.Sprintf("stream %s is at version %d, but version %d was expected", c.StreamId, c.ActualVersion, c.ExpectedVersion)
+}
+
+func (c ConcurrencyError) Is(err error) bool {
+	_, ok := err.(ConcurrencyError)
+
+	return ok
+}
+
+func NewEventStore(path string, logger logging.Logger) (*EventStore, error) {
+	logger.Infof("starting bolt event store: %s", path)
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("could not open event store %s: %v", path, err)
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{streamsBucket, snapshotsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("could not initialize event store %s: %v", path, err)
+	}
+
+	store, err := boltoutbox.NewStore(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &EventStore{
+		Store:  store,
+		logger: logger,
+		db:     db,
+	}, nil
+}
+
+type EventStore struct {
+	*boltoutbox.Store
+	logger logging.Logger
+	db     *bbolt.DB
+}
+
+func (s *EventStore) Close() error {
+	s.logger.Infof("closing bolt event store")
+
+	return s.db.Close()
+}
+
+func (s *EventStore) CheckHealth(ctx context.Context) error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		return nil
+	})
+}
+
+func (s *EventStore) Append(ctx context.Context, streamId string, expectedVersion int, envelopes ...domain.Envelope) (int, error) {
+	version := expectedVersion
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		stream, err := tx.Bucket(streamsBucket).CreateBucketIfNotExists([]byte(streamId))
+		if err != nil {
+			return err
+		}
+
+		if actualVersion := int(stream.Sequence()); actualVersion != expectedVersion {
+			return NewConcurrencyError(streamId, expectedVersion, actualVersion)
+		}
+
+		sequenced := make([]domain.Envelope, 0, len(envelopes))
+		for _, envelope := range envelopes {
+			sequence, err := stream.NextSequence()
+			if err != nil {
+				return err
+			}
+
+			envelope.Metadata.Sequence = int(sequence)
+
+			value, err := json.Marshal(StoredEvent{
+				StreamId:   streamId,
+				Version:    int(sequence),
+				Envelope:   envelope,
+				RecordedAt: time.Now().UTC(),
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := stream.Put(versionKey(sequence), value); err != nil {
+				return err
+			}
+			version = int(sequence)
+			sequenced = append(sequenced, envelope)
+		}
+
+		return s.Store.Add(tx, sequenced...)
+	})
+
+	return version, err
+}
+
+func (s *EventStore) Load(ctx context.Context, streamId string, afterVersion int) ([]StoredEvent, error) {
+	storedEvents := make([]StoredEvent, 0)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		stream := tx.Bucket(streamsBucket).Bucket([]byte(streamId))
+		if stream == nil {
+			return nil
+		}
+
+		cursor := stream.Cursor()
+		for key, value := cursor.Seek(versionKey(uint64(afterVersion + 1))); key != nil; key, value = cursor.Next() {
+			storedEvent := StoredEvent{}
+			if err := json.Unmarshal(value, &storedEvent); err != nil {
+				return err
+			}
+			storedEvents = append(storedEvents, storedEvent)
+		}
+
+		return nil
+	})
+
+	return storedEvents, err
+}
+
+func (s *EventStore) SaveSnapshot(ctx context.Context, snapshot Snapshot) error {
+	value, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(snapshotsBucket).Put([]byte(snapshot.StreamId), value)
+	})
+}
+
+func (s *EventStore) LoadSnapshot(ctx context.Context, streamId string) (Snapshot, bool, error) {
+	snapshot := Snapshot{}
+	found := false
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		value := tx.Bucket(snapshotsBucket).Get([]byte(streamId))
+		if value == nil {
+			return nil
+		}
+
+		found = true
+		return json.Unmarshal(value, &snapshot)
+	})
+
+	return snapshot, found, err
+}
+
+func (s *EventStore) StreamIds(ctx context.Context, after string, limit int) ([]string, error) {
+	streamIds := make([]string, 0, limit)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		cursor := tx.Bucket(streamsBucket).Cursor()
+		key, _ := cursor.First()
+		if after != "" {
+			key, _ = cursor.Seek([]byte(after))
+			if key != nil && string(key) == after {
+				key, _ = cursor.Next()
+			}
+		}
+
+		for ; key != nil && len(streamIds) < limit; key, _ = cursor.Next() {
+			streamIds = append(streamIds, string(key))
+		}
+
+		return nil
+	})
+
+	return streamIds, err
+}
+
+func (s *EventStore) Clear(ctx context.Context) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{streamsBucket, snapshotsBucket} {
+			if err := tx.DeleteBucket(bucket); err != nil {
+				return err
+			}
+
+			if _, err := tx.CreateBucket(bucket); err != nil {
+				return err
+			}
+		}
+
+		return s.Store.Clear(tx)
+	})
+}
+
+func versionKey(version uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, version)
+
+	return key
+}
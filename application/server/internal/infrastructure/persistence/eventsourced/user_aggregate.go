@@ -0,0 +1,67 @@
+package eventsourced
+
+import (
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/events"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+)
+
+type userAggregate struct {
+	User    model.User `json:"user"`
+	Exists  bool       `json:"exists"`
+	Deleted bool       `json:"deleted"`
+	Version int        `json:"version"`
+}
+
+func (a userAggregate) IsAlive() bool {
+	return a.Exists && !a.Deleted
+}
+
+func (a userAggregate) Apply(envelopes ...domain.Envelope) (userAggregate, error) {
+	for _, envelope := range envelopes {
+		event, err := decodeEvent(envelope)
+		if err != nil {
+			return a, err
+		}
+
+		switch event := event.(type) {
+		case *events.NewUserRegistered:
+			a.User = event.User
+			a.Exists = true
+			a.Deleted = false
+		case *events.UserDetailsCorrected:
+			a.User = a.User.CorrectDetails(event.NewUserDetails)
+		case *events.UserDeleted:
+			a.Deleted = true
+		default:
+			return a, fmt.Errorf("unknown user event: %s", envelope.Metadata.Name)
+		}
+
+		a.Version++
+	}
+
+	return a, nil
+}
+
+func decodeEvent(envelope domain.Envelope) (domain.Event, error) {
+	switch event := envelope.Event.(type) {
+	case events.NewUserRegistered:
+		return &event, nil
+	case events.UserDetailsCorrected:
+		return &event, nil
+	case events.UserDeleted:
+		return &event, nil
+	case domain.RawEvent:
+		for _, definition := range events.All() {
+			if definition.GetName() == event.GetDefinition().GetName() {
+				decoded, err := domain.DecodeEnvelope(envelope, definition)
+				return decoded.Event, err
+			}
+		}
+
+		return nil, fmt.Errorf("unknown user event: %s", event.GetDefinition().GetName())
+	default:
+		return envelope.Event, nil
+	}
+}
@@ -0,0 +1,356 @@
+package eventsourced
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/events"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/outbox"
+	"sync"
+	"time"
+)
+
+const (
+	listBatchSize  = 50
+	maxUpdateTries = 3
+)
+
+func NewUserRepository(store *EventStore, snapshotInterval int, logger logging.Logger) (*UserRepository, error) {
+	logger.Infof("starting event sourced user repository")
+	repository := &UserRepository{
+		Store:            store,
+		logger:           logger,
+		events:           store,
+		snapshotInterval: snapshotInterval,
+		lock:             &sync.Mutex{},
+		emails:           make(map[model.Email]model.UserId),
+	}
+
+	if err := repository.indexEmails(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return repository, nil
+}
+
+type UserRepository struct {
+	outbox.Store
+	logger           logging.Logger
+	events           *EventStore
+	snapshotInterval int
+	lock             *sync.Mutex
+	emails           map[model.Email]model.UserId
+}
+
+func (r *UserRepository) Close() error {
+	r.logger.Infof("closing event sourced user repository")
+
+	return r.events.Close()
+}
+
+func (r *UserRepository) CheckHealth(ctx context.Context) error {
+	return r.events.CheckHealth(ctx)
+}
+
+func (r *UserRepository) Clear(ctx context.Context) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.emails = make(map[model.Email]model.UserId)
+
+	return r.events.Clear(ctx)
+}
+
+func (r *UserRepository) AddUser(ctx context.Context, newUser model.User, pending ...domain.Envelope) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	email := newUser.Email
+	if _, present := r.emails[email]; present {
+		return model.NewBadRequest(fmt.Sprintf("user email : %s already exists", email))
+	}
+
+	aggregate, err := r.load(ctx, newUser.Id)
+	if err != nil {
+		return err
+	}
+
+	if aggregate.IsAlive() {
+		return alreadyExists(newUser.Id)
+	}
+
+	if len(pending) == 0 {
+		pending = []domain.Envelope{domain.NewEnvelope(ctx, events.NewUserRegistered{User: newUser})}
+	}
+
+	if _, err := r.append(ctx, aggregate, pending...); err != nil {
+		if errors.Is(err, ConcurrencyError{}) {
+			return alreadyExists(newUser.Id)
+		}
+		return err
+	}
+
+	r.emails[email] = newUser.Id
+
+	return nil
+}
+
+func (r *UserRepository) UpdateUser(ctx context.Context, userId model.UserId, update func(user model.User) model.User, pending ...domain.Envelope) error {
+	var err error
+	for try := 0; try < maxUpdateTries; try++ {
+		var aggregate userAggregate
+		aggregate, err = r.load(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		if !aggregate.IsAlive() {
+			return notFound(userId)
+		}
+
+		var changes []domain.Envelope
+		changes, err = changesOf(ctx, aggregate, update(aggregate.User), pending)
+		if err != nil {
+			return err
+		}
+
+		if _, err = r.append(ctx, aggregate, changes...); !errors.Is(err, ConcurrencyError{}) {
+			return err
+		}
+	}
+
+	return model.NewUnknownError(fmt.Sprintf("could not update user with id: %s", userId), err)
+}
+
+// changesOf returns the events recording the update of the aggregate user.
+// The pending events are kept when they lead to the updated user, otherwise
+// only a details correction can be derived from the update.
+func changesOf(ctx context.Context, aggregate userAggregate, updated model.User, pending []domain.Envelope) ([]domain.Envelope, error) {
+	current := aggregate.User
+	if len(pending) == 0 {
+		if updated.Id != current.Id || updated.Email != current.Email {
+			return nil, model.NewBadRequest(fmt.Sprintf("update of user with id: %s cannot be recorded as an event", current.Id))
+		}
+
+		pending = []domain.Envelope{domain.NewEnvelope(ctx, events.UserDetailsCorrected{
+			UserId:         current.Id,
+			NewUserDetails: updated.Details,
+		})}
+	}
+
+	changed, err := aggregate.Apply(pending...)
+	if err != nil {
+		return nil, model.NewBadRequest(err.Error())
+	}
+
+	if !changed.IsAlive() || changed.User != updated {
+		return nil, model.NewBadRequest(fmt.Sprintf("events of user with id: %s do not match its update", current.Id))
+	}
+
+	return pending, nil
+}
+
+func (r *UserRepository) DeleteUser(ctx context.Context, userId model.UserId, pending ...domain.Envelope) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	aggregate, err := r.load(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if !aggregate.IsAlive() {
+		return notFound(userId)
+	}
+
+	if len(pending) == 0 {
+		pending = []domain.Envelope{domain.NewEnvelope(ctx, events.UserDeleted{UserId: userId})}
+	}
+
+	if _, err := r.append(ctx, aggregate, pending...); err != nil {
+		return err
+	}
+
+	delete(r.emails, aggregate.User.Email)
+
+	return nil
+}
+
+func (r *UserRepository) ListUsers(ctx context.Context, after model.UserId, limit int) ([]model.User, error) {
+	users := make([]model.User, 0, limit)
+	streamAfter := string(after)
+	for len(users) < limit {
+		streamIds, err := r.events.StreamIds(ctx, streamAfter, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, streamId := range streamIds {
+			aggregate, err := r.load(ctx, model.UserId(streamId))
+			if err != nil {
+				return nil, err
+			}
+
+			if aggregate.IsAlive() && len(users) < limit {
+				users = append(users, aggregate.User)
+			}
+		}
+
+		if len(streamIds) < limit {
+			break
+		}
+
+		streamAfter = streamIds[len(streamIds)-1]
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) ListAllUsers(ctx context.Context) model.UserIterator {
+	return model.NewUserIterator(ctx, model.PagedFetcher(listBatchSize, r.ListUsers))
+}
+
+func (r *UserRepository) GetUser(ctx context.Context, userId model.UserId) (model.User, error) {
+	aggregate, err := r.load(ctx, userId)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if !aggregate.IsAlive() {
+		return model.User{}, notFound(userId)
+	}
+
+	return aggregate.User, nil
+}
+
+func (r *UserRepository) GetUserHistory(ctx context.Context, userId model.UserId) ([]model.UserHistoryEntry, error) {
+	storedEvents, err := r.events.Load(ctx, string(userId), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(storedEvents) == 0 {
+		return nil, notFound(userId)
+	}
+
+	history := make([]model.UserHistoryEntry, 0, len(storedEvents))
+	for _, storedEvent := range storedEvents {
+		event, err := decodeEvent(storedEvent.Envelope)
+		if err != nil {
+			return nil, err
+		}
+
+		metadata := storedEvent.Envelope.Metadata
+		history = append(history, model.UserHistoryEntry{
+			Version:       storedEvent.Version,
+			EventId:       metadata.EventId,
+			EventName:     metadata.Name,
+			OccurredAt:    metadata.OccurredAt,
+			CorrelationId: metadata.CorrelationId,
+			Event:         event,
+		})
+	}
+
+	return history, nil
+}
+
+func (r *UserRepository) load(ctx context.Context, userId model.UserId) (userAggregate, error) {
+	aggregate := userAggregate{}
+	snapshot, found, err := r.events.LoadSnapshot(ctx, string(userId))
+	if err != nil {
+		return aggregate, err
+	}
+
+	if found {
+		if err := json.Unmarshal(snapshot.State, &aggregate); err != nil {
+			return aggregate, err
+		}
+	}
+
+	storedEvents, err := r.events.Load(ctx, string(userId), aggregate.Version)
+	if err != nil {
+		return aggregate, err
+	}
+
+	envelopes := make([]domain.Envelope, 0, len(storedEvents))
+	for _, storedEvent := range storedEvents {
+		envelopes = append(envelopes, storedEvent.Envelope)
+	}
+
+	return aggregate.Apply(envelopes...)
+}
+
+func (r *UserRepository) append(ctx context.Context, aggregate userAggregate, envelopes ...domain.Envelope) (userAggregate, error) {
+	changed, err := aggregate.Apply(envelopes...)
+	if err != nil {
+		return aggregate, model.NewBadRequest(err.Error())
+	}
+
+	streamId := string(changed.User.Id)
+	if _, err := r.events.Append(ctx, streamId, aggregate.Version, envelopes...); err != nil {
+		return aggregate, err
+	}
+
+	if r.snapshotInterval > 0 && changed.Version/r.snapshotInterval > aggregate.Version/r.snapshotInterval {
+		r.snapshot(ctx, streamId, changed)
+	}
+
+	return changed, nil
+}
+
+func (r *UserRepository) snapshot(ctx context.Context, streamId string, aggregate userAggregate) {
+	state, err := json.Marshal(aggregate)
+	if err == nil {
+		err = r.events.SaveSnapshot(ctx, Snapshot{
+			StreamId: streamId,
+			Version:  aggregate.Version,
+			State:    state,
+			TakenAt:  time.Now().UTC(),
+		})
+	}
+
+	if err != nil {
+		r.logger.WithContext(ctx).
+			With(logging.UserId, streamId).
+			Warnf("could not snapshot user at version %d: %v", aggregate.Version, err)
+	}
+}
+
+func (r *UserRepository) indexEmails(ctx context.Context) error {
+	after := ""
+	for {
+		streamIds, err := r.events.StreamIds(ctx, after, listBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, streamId := range streamIds {
+			aggregate, err := r.load(ctx, model.UserId(streamId))
+			if err != nil {
+				return err
+			}
+
+			if aggregate.IsAlive() {
+				r.emails[aggregate.User.Email] = aggregate.User.Id
+			}
+		}
+
+		if len(streamIds) < listBatchSize {
+			return nil
+		}
+
+		after = streamIds[len(streamIds)-1]
+	}
+}
+
+func alreadyExists(userId model.UserId) model.BadRequestError {
+	return model.NewBadRequest(fmt.Sprintf("user with id: %s already exists", userId))
+}
+
+func notFound(userId model.UserId) model.NotFoundError {
+	return model.NewNotFoundError(fmt.Sprintf("user with id: %s was not found", userId))
+}
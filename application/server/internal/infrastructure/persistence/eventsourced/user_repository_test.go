@@ -0,0 +1,198 @@
+package eventsourced
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/events"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"path/filepath"
+	"testing"
+)
+
+func TestUserRepository(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.db")
+	repository := newTestRepository(t, path)
+
+	user := testUser(1)
+	if err := repository.AddUser(ctx, user, domain.NewEnvelope(ctx, events.NewUserRegistered{User: user})); err != nil {
+		t.Fatalf("could not add user: %v", err)
+	}
+
+	duplicate := testUser(2)
+	duplicate.Email = user.Email
+	if err := repository.AddUser(ctx, duplicate); !errors.Is(err, model.BadRequestError{}) {
+		t.Fatalf("a %v was expected, but found: %v", model.BadRequestError{}, err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		details := model.UserDetails{Name: fmt.Sprintf("corrected%d", i)}
+		if err := repository.UpdateUser(ctx, user.Id, func(user model.User) model.User {
+			return user.CorrectDetails(details)
+		}, domain.NewEnvelope(ctx, events.UserDetailsCorrected{UserId: user.Id, NewUserDetails: details})); err != nil {
+			t.Fatalf("could not update user: %v", err)
+		}
+	}
+
+	if _, err := repository.events.Append(ctx, string(user.Id), 2); !errors.Is(err, ConcurrencyError{}) {
+		t.Fatalf("a %v was expected, but found: %v", ConcurrencyError{}, err)
+	}
+
+	if err := repository.Close(); err != nil {
+		t.Fatalf("could not close repository: %v", err)
+	}
+
+	repository = newTestRepository(t, path)
+	defer repository.Close()
+
+	snapshot, found, err := repository.events.LoadSnapshot(ctx, string(user.Id))
+	if err != nil || !found || snapshot.Version != 3 {
+		t.Fatalf("expected a snapshot at version 3, but got: %v, %v, %v", snapshot, found, err)
+	}
+
+	persistedUser, err := repository.GetUser(ctx, user.Id)
+	if err != nil || persistedUser.Details.Name != "corrected4" {
+		t.Fatalf("expected corrected user after reopening, but got: %v, %v", persistedUser, err)
+	}
+
+	if err := repository.AddUser(ctx, duplicate); !errors.Is(err, model.BadRequestError{}) {
+		t.Fatalf("email index was not rebuilt, a %v was expected, but found: %v", model.BadRequestError{}, err)
+	}
+
+	if err := repository.DeleteUser(ctx, user.Id); err != nil {
+		t.Fatalf("could not delete user: %v", err)
+	}
+
+	if _, err := repository.GetUser(ctx, user.Id); !errors.Is(err, model.NotFoundError{}) {
+		t.Fatalf("a %v was expected, but found: %v", model.NotFoundError{}, err)
+	}
+
+	history, err := repository.GetUserHistory(ctx, user.Id)
+	if err != nil || len(history) != 6 {
+		t.Fatalf("expected 6 history entries, but got: %v, %v", history, err)
+	}
+
+	if history[0].EventName != "NewUserRegistered" || history[5].EventName != "UserDeleted" || history[5].Version != 6 {
+		t.Fatalf("unexpected history: %v", history)
+	}
+
+	if count, err := repository.CountPendingEvents(ctx); err != nil || count != 6 {
+		t.Fatalf("expected 6 pending events, but got: %d, %v", count, err)
+	}
+}
+
+func TestUserRepository_RegisteredAgainAfterDeletion(t *testing.T) {
+	ctx := context.Background()
+	repository := newTestRepository(t, filepath.Join(t.TempDir(), "events.db"))
+	defer repository.Close()
+
+	user := testUser(1)
+	if err := repository.AddUser(ctx, user); err != nil {
+		t.Fatalf("could not add user: %v", err)
+	}
+
+	if err := repository.AddUser(ctx, user); !errors.Is(err, model.BadRequestError{}) {
+		t.Fatalf("a %v was expected, but found: %v", model.BadRequestError{}, err)
+	}
+
+	if err := repository.DeleteUser(ctx, user.Id); err != nil {
+		t.Fatalf("could not delete user: %v", err)
+	}
+
+	registered := testUser(1)
+	registered.Email = "email1bis"
+	if err := repository.AddUser(ctx, registered); err != nil {
+		t.Fatalf("could not register user again: %v", err)
+	}
+
+	persistedUser, err := repository.GetUser(ctx, user.Id)
+	if err != nil || persistedUser != registered {
+		t.Fatalf("expected %v, but got: %v, %v", registered, persistedUser, err)
+	}
+
+	history, err := repository.GetUserHistory(ctx, user.Id)
+	if err != nil || len(history) != 3 || history[2].EventName != "NewUserRegistered" {
+		t.Fatalf("unexpected history: %v, %v", history, err)
+	}
+
+	pending, err := repository.PendingEvents(ctx, 10)
+	if err != nil || len(pending) != 3 {
+		t.Fatalf("expected 3 pending events, but got: %v, %v", pending, err)
+	}
+
+	for i, record := range pending {
+		if record.Envelope.Metadata.Sequence != history[i].Version {
+			t.Fatalf("expected events to be sequenced by stream version, but got: %v", record.Envelope.Metadata)
+		}
+	}
+}
+
+func TestUserRepository_UpdateUser(t *testing.T) {
+	ctx := context.Background()
+	repository := newTestRepository(t, filepath.Join(t.TempDir(), "events.db"))
+	defer repository.Close()
+
+	user := testUser(1)
+	if err := repository.AddUser(ctx, user); err != nil {
+		t.Fatalf("could not add user: %v", err)
+	}
+
+	details := model.UserDetails{Name: "corrected"}
+	correctDetails := func(user model.User) model.User {
+		return user.CorrectDetails(details)
+	}
+	changeEmail := func(user model.User) model.User {
+		user.Email = "changed"
+		return user
+	}
+
+	if err := repository.UpdateUser(ctx, user.Id, changeEmail); !errors.Is(err, model.BadRequestError{}) {
+		t.Fatalf("a %v was expected, but found: %v", model.BadRequestError{}, err)
+	}
+
+	other := domain.NewEnvelope(ctx, events.UserDetailsCorrected{UserId: user.Id, NewUserDetails: model.UserDetails{Name: "other"}})
+	if err := repository.UpdateUser(ctx, user.Id, correctDetails, other); !errors.Is(err, model.BadRequestError{}) {
+		t.Fatalf("a %v was expected, but found: %v", model.BadRequestError{}, err)
+	}
+
+	if err := repository.UpdateUser(ctx, user.Id, correctDetails); err != nil {
+		t.Fatalf("could not update user: %v", err)
+	}
+
+	persistedUser, err := repository.GetUser(ctx, user.Id)
+	if err != nil || persistedUser != user.CorrectDetails(details) {
+		t.Fatalf("expected corrected user, but got: %v, %v", persistedUser, err)
+	}
+
+	history, err := repository.GetUserHistory(ctx, user.Id)
+	if err != nil || len(history) != 2 || history[1].EventName != "UserDetailsCorrected" {
+		t.Fatalf("unexpected history: %v, %v", history, err)
+	}
+}
+
+func newTestRepository(t *testing.T, path string) *UserRepository {
+	store, err := NewEventStore(path, logging.NewDiscardLogger())
+	if err != nil {
+		t.Fatalf("could not open event store: %v", err)
+	}
+
+	repository, err := NewUserRepository(store, 3, logging.NewDiscardLogger())
+	if err != nil {
+		t.Fatalf("could not open repository: %v", err)
+	}
+
+	return repository
+}
+
+func testUser(number int) model.User {
+	return model.User{
+		Id: model.UserId(fmt.Sprintf("user%d", number)),
+		Details: model.UserDetails{
+			Name: fmt.Sprintf("name%d", number),
+		},
+		Email: model.Email(fmt.Sprintf("email%d", number)),
+	}
+}
@@ -4,28 +4,43 @@ import (
 	"context"
 	"fmt"
 	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
-	"log"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/outbox"
 	"sync"
+	"time"
 )
 
-func NewUserRepository() *UserRepository {
-	log.Println("starting inmemory user repository")
+const (
+	listBatchSize = 50
+)
+
+func NewUserRepository(logger logging.Logger) *UserRepository {
+	logger.Infof("starting inmemory user repository")
 	return &UserRepository{
+		logger: logger,
 		lock:   &sync.RWMutex{},
 		users:  make(map[model.UserId]model.User),
 		emails: make(map[model.Email]model.UserId),
+		outbox: outbox.NewQueue(),
 	}
 }
 
 type UserRepository struct {
+	logger logging.Logger
 	lock   *sync.RWMutex
 	users  map[model.UserId]model.User
 	emails map[model.Email]model.UserId
+	outbox *outbox.Queue
 }
 
 func (r *UserRepository) Close() error {
-	log.Println("closing inmemory user repository")
+	r.logger.Infof("closing inmemory user repository")
+
+	return nil
+}
 
+func (r *UserRepository) CheckHealth(ctx context.Context) error {
 	return nil
 }
 
@@ -35,11 +50,12 @@ func (r *UserRepository) Clear(ctx context.Context) error {
 
 	r.users = make(map[model.UserId]model.User)
 	r.emails = make(map[model.Email]model.UserId)
+	r.outbox.Clear()
 
 	return nil
 }
 
-func (r *UserRepository) AddUser(ctx context.Context, newUser model.User) error {
+func (r *UserRepository) AddUser(ctx context.Context, newUser model.User, pending ...domain.Envelope) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
@@ -55,29 +71,32 @@ func (r *UserRepository) AddUser(ctx context.Context, newUser model.User) error
 
 	r.users[userId] = newUser
 	r.emails[email] = userId
+	r.outbox.Add(outbox.NewRecords(pending...)...)
 
 	return nil
 }
 
-func (r *UserRepository) UpdateUser(ctx context.Context, userId model.UserId, update func(user model.User) model.User) error {
+func (r *UserRepository) UpdateUser(ctx context.Context, userId model.UserId, update func(user model.User) model.User, pending ...domain.Envelope) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
 	if user, present := r.users[userId]; present {
 		r.users[userId] = update(user)
+		r.outbox.Add(outbox.NewRecords(pending...)...)
 		return nil
 	}
 
 	return notFound(userId)
 }
 
-func (r *UserRepository) DeleteUser(ctx context.Context, userId model.UserId) error {
+func (r *UserRepository) DeleteUser(ctx context.Context, userId model.UserId, pending ...domain.Envelope) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
 	if user, present := r.users[userId]; present {
 		delete(r.users, userId)
 		delete(r.emails, user.Email)
+		r.outbox.Add(outbox.NewRecords(pending...)...)
 
 		return nil
 	}
@@ -85,27 +104,28 @@ func (r *UserRepository) DeleteUser(ctx context.Context, userId model.UserId) er
 	return notFound(userId)
 }
 
-func (r *UserRepository) ListAllUsers(ctx context.Context, next <-chan bool) (<-chan model.User, error) {
-	users := make(chan model.User)
-	go func() {
-		r.lock.RLock()
-		defer r.lock.RUnlock()
-		defer close(users)
-
-		for _, user := range OrderedMap(r.users).OrderedValues() {
-			users <- user
-			select {
-			case needNext := <-next:
-				if !needNext {
-					break
-				}
-			}
+func (r *UserRepository) ListUsers(ctx context.Context, after model.UserId, limit int) ([]model.User, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	users := make([]model.User, 0, limit)
+	for _, user := range OrderedMap(r.users).OrderedValues() {
+		if len(users) >= limit {
+			break
 		}
-	}()
+
+		if user.Id > after {
+			users = append(users, user)
+		}
+	}
 
 	return users, nil
 }
 
+func (r *UserRepository) ListAllUsers(ctx context.Context) model.UserIterator {
+	return model.NewUserIterator(ctx, model.PagedFetcher(listBatchSize, r.ListUsers))
+}
+
 func (r *UserRepository) GetUser(ctx context.Context, userId model.UserId) (model.User, error) {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
@@ -117,6 +137,34 @@ func (r *UserRepository) GetUser(ctx context.Context, userId model.UserId) (mode
 	return model.User{}, notFound(userId)
 }
 
+func (r *UserRepository) PendingEvents(ctx context.Context, limit int) ([]outbox.Record, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.outbox.Pending(time.Now().UTC(), limit), nil
+}
+
+func (r *UserRepository) CountPendingEvents(ctx context.Context) (int, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.outbox.Count(), nil
+}
+
+func (r *UserRepository) MarkPublished(ctx context.Context, id string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.outbox.Remove(id)
+}
+
+func (r *UserRepository) MarkFailed(ctx context.Context, id string, cause error, nextAttemptAt time.Time) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.outbox.Fail(id, cause, nextAttemptAt)
+}
+
 func notFound(userId model.UserId) model.NotFoundError {
 	return model.NewNotFoundError(fmt.Sprintf("user with id: %s was not found", userId))
 }
@@ -0,0 +1,113 @@
+package persistence
+
+import (
+	"context"
+	"github.com/frederic-gendebien/pact-poc/application/server/internal/domain/repository"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/metrics"
+	"github.com/frederic-gendebien/pact-poc/lib/outbox"
+	"github.com/frederic-gendebien/pact-poc/lib/tracing"
+	"time"
+)
+
+const (
+	instrumentedRepositoryName = "user"
+)
+
+func NewInstrumentedUserRepository(userRepository repository.UserRepository) repository.UserRepository {
+	instrumented := &InstrumentedUserRepository{
+		UserRepository: userRepository,
+	}
+
+	if history, ok := userRepository.(repository.UserHistoryRepository); ok {
+		return &instrumentedUserHistoryRepository{
+			InstrumentedUserRepository: instrumented,
+			history:                    history,
+		}
+	}
+
+	return instrumented
+}
+
+type InstrumentedUserRepository struct {
+	repository.UserRepository
+}
+
+func (i *InstrumentedUserRepository) AddUser(ctx context.Context, newUser model.User, pending ...domain.Envelope) (err error) {
+	ctx, done := observe(ctx, "add_user")
+	defer done(&err)
+
+	return i.UserRepository.AddUser(ctx, newUser, pending...)
+}
+
+func (i *InstrumentedUserRepository) UpdateUser(ctx context.Context, userId model.UserId, update func(user model.User) model.User, pending ...domain.Envelope) (err error) {
+	ctx, done := observe(ctx, "update_user")
+	defer done(&err)
+
+	return i.UserRepository.UpdateUser(ctx, userId, update, pending...)
+}
+
+func (i *InstrumentedUserRepository) DeleteUser(ctx context.Context, userId model.UserId, pending ...domain.Envelope) (err error) {
+	ctx, done := observe(ctx, "delete_user")
+	defer done(&err)
+
+	return i.UserRepository.DeleteUser(ctx, userId, pending...)
+}
+
+func (i *InstrumentedUserRepository) ListUsers(ctx context.Context, after model.UserId, limit int) (users []model.User, err error) {
+	ctx, done := observe(ctx, "list_users")
+	defer done(&err)
+
+	return i.UserRepository.ListUsers(ctx, after, limit)
+}
+
+func (i *InstrumentedUserRepository) GetUser(ctx context.Context, userId model.UserId) (user model.User, err error) {
+	ctx, done := observe(ctx, "get_user")
+	defer done(&err)
+
+	return i.UserRepository.GetUser(ctx, userId)
+}
+
+func (i *InstrumentedUserRepository) PendingEvents(ctx context.Context, limit int) (records []outbox.Record, err error) {
+	ctx, done := observe(ctx, "pending_events")
+	defer done(&err)
+
+	return i.UserRepository.PendingEvents(ctx, limit)
+}
+
+func (i *InstrumentedUserRepository) MarkPublished(ctx context.Context, id string) (err error) {
+	ctx, done := observe(ctx, "mark_published")
+	defer done(&err)
+
+	return i.UserRepository.MarkPublished(ctx, id)
+}
+
+func (i *InstrumentedUserRepository) MarkFailed(ctx context.Context, id string, cause error, nextAttemptAt time.Time) (err error) {
+	ctx, done := observe(ctx, "mark_failed")
+	defer done(&err)
+
+	return i.UserRepository.MarkFailed(ctx, id, cause, nextAttemptAt)
+}
+
+type instrumentedUserHistoryRepository struct {
+	*InstrumentedUserRepository
+	history repository.UserHistoryRepository
+}
+
+func (i *instrumentedUserHistoryRepository) GetUserHistory(ctx context.Context, userId model.UserId) (entries []model.UserHistoryEntry, err error) {
+	ctx, done := observe(ctx, "get_user_history")
+	defer done(&err)
+
+	return i.history.GetUserHistory(ctx, userId)
+}
+
+func observe(ctx context.Context, operation string) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, instrumentedRepositoryName+" repository "+operation)
+
+	return ctx, func(err *error) {
+		tracing.End(span, *err)
+		metrics.ObserveRepositoryOperation(instrumentedRepositoryName, operation, start, *err)
+	}
+}
@@ -0,0 +1,76 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"github.com/frederic-gendebien/pact-poc/application/server/internal/domain/repository"
+	"github.com/frederic-gendebien/pact-poc/application/server/internal/infrastructure/persistence/eventsourced"
+	"github.com/frederic-gendebien/pact-poc/application/server/internal/infrastructure/persistence/inmemory"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/metrics"
+	"github.com/frederic-gendebien/pact-poc/lib/metrics/metricstest"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstrumentedUserRepository(t *testing.T) {
+	ctx := context.Background()
+	instrumented := NewInstrumentedUserRepository(inmemory.NewUserRepository(logging.NewDiscardLogger()))
+	if _, ok := instrumented.(repository.UserHistoryRepository); ok {
+		t.Fatalf("expected no user history without an event sourced repository")
+	}
+
+	added, missing := observations(t, "add_user", metrics.OutcomeSuccess), observations(t, "get_user", metrics.OutcomeFailure)
+	user := model.User{Id: "user1", Details: model.UserDetails{Name: "name1"}, Email: "email1"}
+	if err := instrumented.AddUser(ctx, user); err != nil {
+		t.Fatalf("could not add user: %v", err)
+	}
+
+	if _, err := instrumented.GetUser(ctx, "unknown"); !errors.Is(err, model.NotFoundError{}) {
+		t.Fatalf("a %v was expected, but found: %v", model.NotFoundError{}, err)
+	}
+
+	if count := observations(t, "add_user", metrics.OutcomeSuccess) - added; count != 1 {
+		t.Fatalf("expected 1 successful add_user observation, but got: %d", count)
+	}
+
+	if count := observations(t, "get_user", metrics.OutcomeFailure) - missing; count != 1 {
+		t.Fatalf("expected 1 failed get_user observation, but got: %d", count)
+	}
+}
+
+func TestInstrumentedUserRepository_History(t *testing.T) {
+	store, err := eventsourced.NewEventStore(filepath.Join(t.TempDir(), "events.db"), logging.NewDiscardLogger())
+	if err != nil {
+		t.Fatalf("could not open event store: %v", err)
+	}
+
+	eventSourced, err := eventsourced.NewUserRepository(store, 0, logging.NewDiscardLogger())
+	if err != nil {
+		t.Fatalf("could not open repository: %v", err)
+	}
+	defer eventSourced.Close()
+
+	history, ok := NewInstrumentedUserRepository(eventSourced).(repository.UserHistoryRepository)
+	if !ok {
+		t.Fatalf("expected the user history of the event sourced repository to be kept")
+	}
+
+	before := observations(t, "get_user_history", metrics.OutcomeFailure)
+	if _, err := history.GetUserHistory(context.Background(), "unknown"); !errors.Is(err, model.NotFoundError{}) {
+		t.Fatalf("a %v was expected, but found: %v", model.NotFoundError{}, err)
+	}
+
+	if count := observations(t, "get_user_history", metrics.OutcomeFailure) - before; count != 1 {
+		t.Fatalf("expected 1 failed get_user_history observation, but got: %d", count)
+	}
+}
+
+func observations(t *testing.T, operation string, outcome string) uint64 {
+	return metricstest.SampleCount(t, "repository_operation_duration_seconds", map[string]string{
+		"repository": instrumentedRepositoryName,
+		"operation":  operation,
+		"outcome":    outcome,
+	})
+}
@@ -2,23 +2,99 @@ package persistence
 
 import (
 	"github.com/frederic-gendebien/pact-poc/application/server/internal/domain/repository"
+	"github.com/frederic-gendebien/pact-poc/application/server/internal/infrastructure/persistence/bolt"
+	"github.com/frederic-gendebien/pact-poc/application/server/internal/infrastructure/persistence/eventsourced"
 	"github.com/frederic-gendebien/pact-poc/application/server/internal/infrastructure/persistence/inmemory"
 	"github.com/frederic-gendebien/pact-poc/lib/config"
-	"log"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
 )
 
 const (
 	Mode         = "PERSISTENCE_MODE"
 	ModeInMemory = "inmemory"
+	ModeBolt     = "bolt"
+	BoltPath     = "PERSISTENCE_BOLT_PATH"
+
+	ModeEventSourced = "eventsourced"
+	EventStorePath   = "PERSISTENCE_EVENTSTORE_PATH"
+	SnapshotInterval = "PERSISTENCE_SNAPSHOT_INTERVAL"
 )
 
-func NewUserRepository(configuration config.Configuration) repository.UserRepository {
-	mode := configuration.GetStringOrCrash(Mode)
+func init() {
+	config.Register(config.Property{
+		Name:        Mode,
+		Required:    true,
+		Allowed:     []string{ModeInMemory, ModeBolt, ModeEventSourced},
+		Description: "user repository implementation",
+	})
+	config.Register(config.When(Mode, ModeBolt, config.Property{
+		Name:        BoltPath,
+		Required:    true,
+		Description: "bolt database file",
+	})...)
+	config.Register(config.When(Mode, ModeEventSourced,
+		config.Property{
+			Name:        EventStorePath,
+			Required:    true,
+			Description: "event store database file",
+		},
+		config.Property{
+			Name:        SnapshotInterval,
+			Type:        config.TypeInt,
+			Default:     "10",
+			Description: "number of events between two snapshots of a user",
+		},
+	)...)
+}
+
+func NewUserRepository(configuration config.Configuration, logger logging.Logger) repository.UserRepository {
+	mode, err := config.MandatoryString(configuration, Mode)
+	if err != nil {
+		logger.Fatalf("invalid persistence configuration: %v", err)
+	}
+
 	switch mode {
 	case ModeInMemory:
-		return inmemory.NewUserRepository()
+		return inmemory.NewUserRepository(logger)
+	case ModeBolt:
+		path, err := config.MandatoryString(configuration, BoltPath)
+		if err != nil {
+			logger.Fatalf("invalid persistence configuration: %v", err)
+		}
+
+		repository, err := bolt.NewUserRepository(path, logger)
+		if err != nil {
+			logger.Fatalf("could not start bolt user repository: %v", err)
+		}
+		return repository
+	case ModeEventSourced:
+		return newEventSourcedUserRepository(configuration, logger)
 	default:
-		log.Fatalf("unknown persistence mode: %s", mode)
+		logger.Fatalf("unknown persistence mode: %s", mode)
 		return nil
 	}
 }
+
+func newEventSourcedUserRepository(configuration config.Configuration, logger logging.Logger) repository.UserRepository {
+	snapshotInterval, err := config.Int(configuration, SnapshotInterval)
+	if err != nil {
+		logger.Fatalf("invalid snapshot interval: %v", err)
+	}
+
+	path, err := config.MandatoryString(configuration, EventStorePath)
+	if err != nil {
+		logger.Fatalf("invalid persistence configuration: %v", err)
+	}
+
+	store, err := eventsourced.NewEventStore(path, logger)
+	if err != nil {
+		logger.Fatalf("could not start event store: %v", err)
+	}
+
+	repository, err := eventsourced.NewUserRepository(store, snapshotInterval, logger)
+	if err != nil {
+		logger.Fatalf("could not start event sourced user repository: %v", err)
+	}
+
+	return repository
+}
@@ -1,9 +1,11 @@
 package http
 
 import (
+	"fmt"
 	"github.com/frederic-gendebien/pact-poc/application/server/internal/usecase"
 	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
 	"github.com/gin-gonic/gin"
+	"net/url"
 	"strconv"
 )
 
@@ -18,6 +20,7 @@ func addUserHandlers(engine *gin.Engine, useCase usecase.UserUseCase) {
 	users.DELETE(":user_id", deleteUser(useCase))
 	users.GET("", getUsers(useCase))
 	users.GET(":user_id", getUser(useCase))
+	users.GET(":user_id/history", getUserHistory(useCase))
 }
 
 func registerNewUser(useCase usecase.UserUseCase) gin.HandlerFunc {
@@ -28,7 +31,7 @@ func registerNewUser(useCase usecase.UserUseCase) gin.HandlerFunc {
 			return
 		}
 
-		createdOrFail(ctx, useCase.RegisterNewUser(ctx, newUser))
+		createdOrFail(ctx, useCase.RegisterNewUser(ctx.Request.Context(), newUser))
 	}
 }
 
@@ -46,7 +49,7 @@ func correctDetails(useCase usecase.UserUseCase) gin.HandlerFunc {
 			return
 		}
 
-		acceptedOrFail(ctx, useCase.CorrectUserDetails(ctx, model.UserId(userId), newUserDetails))
+		acceptedOrFail(ctx, useCase.CorrectUserDetails(ctx.Request.Context(), model.UserId(userId), newUserDetails))
 	}
 }
 
@@ -58,19 +61,21 @@ func deleteUser(useCase usecase.UserUseCase) gin.HandlerFunc {
 			return
 		}
 
-		acceptedOrFail(ctx, useCase.DeleteUser(ctx, model.UserId(userId)))
+		acceptedOrFail(ctx, useCase.DeleteUser(ctx.Request.Context(), model.UserId(userId)))
 	}
 }
 
 func getUsers(useCase usecase.UserUseCase) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		limit := minOrDefault(ctx.Query("limit"), MaxLimit)
-		next := make(chan bool)
-		defer close(next)
-
-		users, err := useCase.ListAllUsers(ctx, next)
+		page, err := useCase.ListUsers(ctx.Request.Context(), model.Cursor(ctx.Query("after")), limit)
+		if err == nil && page.HasNext() {
+			ctx.Header("Link", nextLink(ctx, page.Next, limit))
+		}
 
-		okOrFail(ctx, err, accumulateUsers(users, limit, next))
+		okOrFail(ctx, err, func() interface{} {
+			return page.Users
+		})
 	}
 }
 
@@ -80,7 +85,7 @@ func minOrDefault(value string, maxValue int) int {
 	}
 
 	intValue, err := strconv.Atoi(value)
-	if err != nil {
+	if err != nil || intValue < 1 {
 		return maxValue
 	}
 
@@ -91,24 +96,12 @@ func minOrDefault(value string, maxValue int) int {
 	return intValue
 }
 
-func accumulateUsers(users <-chan model.User, limit int, next chan<- bool) func() interface{} {
-	return func() interface{} {
-		counter := 0
-		results := make([]model.User, 0, MaxLimit)
-
-		for user := range users {
-			if counter >= limit {
-				next <- false
-				break
-			} else {
-				results = append(results, user)
-				counter++
-				next <- true
-			}
-		}
+func nextLink(ctx *gin.Context, next model.Cursor, limit int) string {
+	query := url.Values{}
+	query.Set("after", string(next))
+	query.Set("limit", strconv.Itoa(limit))
 
-		return results
-	}
+	return fmt.Sprintf(`<%s?%s>; rel="next"`, ctx.Request.URL.Path, query.Encode())
 }
 
 func getUser(useCase usecase.UserUseCase) gin.HandlerFunc {
@@ -119,10 +112,26 @@ func getUser(useCase usecase.UserUseCase) gin.HandlerFunc {
 			return
 		}
 
-		user, err := useCase.FindUserById(ctx, model.UserId(userId))
+		user, err := useCase.FindUserById(ctx.Request.Context(), model.UserId(userId))
 
 		okOrFail(ctx, err, func() interface{} {
 			return user
 		})
 	}
 }
+
+func getUserHistory(useCase usecase.UserUseCase) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId := ctx.Param("user_id")
+		if userId == "" {
+			fail(ctx, model.NewBadRequest("wrong user id"))
+			return
+		}
+
+		history, err := useCase.GetUserHistory(ctx.Request.Context(), model.UserId(userId))
+
+		okOrFail(ctx, err, func() interface{} {
+			return history
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/health"
+	"github.com/frederic-gendebien/pact-poc/lib/openapi"
+	"github.com/frederic-gendebien/pact-poc/lib/outbox"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	APIVersion = "1.0.0"
+)
+
+func addOpenAPIHandlers(engine *gin.Engine) {
+	engine.GET("/openapi.json", openapi.Handler(Specification()))
+}
+
+// Specification documents every route registered by NewServer, the request
+// and response schemas being generated from the model types.
+func Specification() *openapi.Document {
+	document := openapi.NewDocument(ServiceName, APIVersion)
+	badRequest := document.JSONResponse("invalid request", ErrorResponse{})
+	notFound := document.JSONResponse("user not found", ErrorResponse{})
+	failure := document.JSONResponse("unexpected failure", ErrorResponse{})
+
+	return document.
+		Add("PUT", "/users", openapi.Operation{
+			OperationId: "registerNewUser",
+			Summary:     "register a new user",
+			Tags:        []string{"users"},
+			RequestBody: document.JSONBody(model.User{}),
+			Responses: map[string]openapi.Response{
+				"201": openapi.EmptyResponse("user registered"),
+				"400": badRequest,
+				"500": failure,
+			},
+		}).
+		Add("PUT", "/users/:user_id/details", openapi.Operation{
+			OperationId: "correctUserDetails",
+			Summary:     "correct the details of a user",
+			Tags:        []string{"users"},
+			RequestBody: document.JSONBody(model.UserDetails{}),
+			Responses: map[string]openapi.Response{
+				"202": openapi.EmptyResponse("correction accepted"),
+				"400": badRequest,
+				"404": notFound,
+				"500": failure,
+			},
+		}).
+		Add("DELETE", "/users/:user_id", openapi.Operation{
+			OperationId: "deleteUser",
+			Summary:     "delete a user",
+			Tags:        []string{"users"},
+			Responses: map[string]openapi.Response{
+				"202": openapi.EmptyResponse("deletion accepted"),
+				"400": badRequest,
+				"404": notFound,
+				"500": failure,
+			},
+		}).
+		Add("GET", "/users", openapi.Operation{
+			OperationId: "listUsers",
+			Summary:     "list the users, one page at a time",
+			Tags:        []string{"users"},
+			Parameters: []openapi.Parameter{
+				openapi.QueryParameter("limit", openapi.Integer(1, MaxLimit), false, "size of the page"),
+				openapi.QueryParameter("after", &openapi.Schema{Type: openapi.TypeString}, false, "cursor of the page, taken from the next link"),
+			},
+			Responses: map[string]openapi.Response{
+				"200": {
+					Description: "a page of users",
+					Headers: map[string]openapi.Header{
+						"Link": {
+							Description: `link to the next page, with rel="next", when there is one`,
+							Schema:      &openapi.Schema{Type: openapi.TypeString},
+						},
+					},
+					Content: document.JSON([]model.User{}),
+				},
+				"500": failure,
+			},
+		}).
+		Add("GET", "/users/:user_id", openapi.Operation{
+			OperationId: "getUser",
+			Summary:     "get a user",
+			Tags:        []string{"users"},
+			Responses: map[string]openapi.Response{
+				"200": document.JSONResponse("the user", model.User{}),
+				"400": badRequest,
+				"404": notFound,
+				"500": failure,
+			},
+		}).
+		Add("GET", "/users/:user_id/history", openapi.Operation{
+			OperationId: "getUserHistory",
+			Summary:     "get the events of a user",
+			Tags:        []string{"users"},
+			Responses: map[string]openapi.Response{
+				"200": document.JSONResponse("the history of the user", []model.UserHistoryEntry{}),
+				"400": badRequest,
+				"404": notFound,
+				"500": failure,
+			},
+		}).
+		Add("GET", "/outbox", openapi.Operation{
+			OperationId: "getOutboxStatus",
+			Summary:     "get the status of the outbox relay",
+			Tags:        []string{"operations"},
+			Responses: map[string]openapi.Response{
+				"200": document.JSONResponse("the outbox status", outbox.Status{}),
+				"500": failure,
+			},
+		}).
+		Add("GET", "/health/live", openapi.Operation{
+			OperationId: "getLiveness",
+			Tags:        []string{"operations"},
+			Responses: map[string]openapi.Response{
+				"200": document.JSONResponse("the service is live", health.Report{}),
+				"503": document.JSONResponse("the service is not live", health.Report{}),
+			},
+		}).
+		Add("GET", "/health/ready", openapi.Operation{
+			OperationId: "getReadiness",
+			Tags:        []string{"operations"},
+			Responses: map[string]openapi.Response{
+				"200": document.JSONResponse("the service is ready", health.Report{}),
+				"503": document.JSONResponse("the service is not ready", health.Report{}),
+			},
+		}).
+		Add("GET", "/metrics", openapi.Operation{
+			OperationId: "getMetrics",
+			Summary:     "prometheus metrics",
+			Tags:        []string{"operations"},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "the metrics", Content: openapi.Text()},
+			},
+		}).
+		Add("GET", "/openapi.json", openapi.Operation{
+			OperationId: "getSpecification",
+			Summary:     "this document",
+			Tags:        []string{"operations"},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "the OpenAPI document", Content: map[string]openapi.MediaType{
+					openapi.ContentTypeJSON: {Schema: &openapi.Schema{Type: openapi.TypeObject}},
+				}},
+			},
+		})
+}
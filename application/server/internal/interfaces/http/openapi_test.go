@@ -0,0 +1,31 @@
+package http
+
+import (
+	inmemorypers "github.com/frederic-gendebien/pact-poc/application/server/internal/infrastructure/persistence/inmemory"
+	"github.com/frederic-gendebien/pact-poc/application/server/internal/usecase"
+	"github.com/frederic-gendebien/pact-poc/lib/config/environment"
+	inmemoryevb "github.com/frederic-gendebien/pact-poc/lib/eventbus/inmemory"
+	"github.com/frederic-gendebien/pact-poc/lib/health"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/openapi"
+	"github.com/frederic-gendebien/pact-poc/lib/outbox"
+	"github.com/gin-gonic/gin"
+	"testing"
+)
+
+func newEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	configuration := environment.NewConfiguration()
+	logger := logging.NewDiscardLogger()
+	repository := inmemorypers.NewUserRepository(logger)
+	relay := outbox.NewRelay(configuration, repository, inmemoryevb.NewEventBus(logger), logger)
+	server := NewServer(configuration, usecase.NewUserUseCase(repository, relay, logger), relay, health.NewHealth(configuration, logger), logger)
+
+	return server.server.Handler.(*gin.Engine)
+}
+
+func TestSpecificationCoversRoutes(t *testing.T) {
+	if undocumented := openapi.Undocumented(newEngine(), Specification()); len(undocumented) > 0 {
+		t.Fatalf("expected every route to be in the specification, but missing: %+v", undocumented)
+	}
+}
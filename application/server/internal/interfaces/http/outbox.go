@@ -0,0 +1,20 @@
+package http
+
+import (
+	"github.com/frederic-gendebien/pact-poc/lib/outbox"
+	"github.com/gin-gonic/gin"
+)
+
+func addOutboxHandlers(engine *gin.Engine, relay *outbox.Relay) {
+	engine.GET("/outbox", getOutboxStatus(relay))
+}
+
+func getOutboxStatus(relay *outbox.Relay) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		status, err := relay.Status(ctx)
+
+		okOrFail(ctx, err, func() interface{} {
+			return status
+		})
+	}
+}
@@ -1,23 +1,69 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"github.com/frederic-gendebien/pact-poc/application/server/internal/usecase"
+	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/frederic-gendebien/pact-poc/lib/health"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/metrics"
+	"github.com/frederic-gendebien/pact-poc/lib/outbox"
 	"github.com/gin-gonic/gin"
+	gohttp "net/http"
 )
 
+const (
+	Port        = "PORT"
+	defaultPort = "8080"
+)
+
+func init() {
+	config.Register(config.Property{
+		Name:        Port,
+		Type:        config.TypeInt,
+		Default:     defaultPort,
+		Description: "port the http server listens on",
+	})
+}
+
 type Server struct {
-	engine *gin.Engine
+	server *gohttp.Server
+	logger logging.Logger
 }
 
-func NewServer(useCase usecase.UserUseCase) *Server {
-	engine := gin.Default()
+func NewServer(configuration config.Configuration, useCase usecase.UserUseCase, relay *outbox.Relay, checks *health.Health, logger logging.Logger) *Server {
+	engine := gin.New()
+	addTracingMiddleware(engine)
+	logging.AddGinMiddleware(engine, logger)
+	metrics.AddGinHandlers(engine)
 	addUserHandlers(engine, useCase)
+	addOutboxHandlers(engine, relay)
+	health.AddGinHandlers(engine, checks)
+	addOpenAPIHandlers(engine)
 
 	return &Server{
-		engine: engine,
+		server: &gohttp.Server{
+			Addr:    address(configuration),
+			Handler: engine,
+		},
+		logger: logger,
+	}
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	s.logger.Infof("listening and serving HTTP on %s", s.server.Addr)
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, gohttp.ErrServerClosed) {
+		return err
 	}
+
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
 }
 
-func (s *Server) Start() error {
-	return s.engine.Run()
+func address(configuration config.Configuration) string {
+	return ":" + config.String(configuration, Port)
 }
@@ -9,6 +9,9 @@ import (
 	"github.com/frederic-gendebien/pact-poc/lib/config"
 	"github.com/frederic-gendebien/pact-poc/lib/config/environment"
 	inmemoryevb "github.com/frederic-gendebien/pact-poc/lib/eventbus/inmemory"
+	"github.com/frederic-gendebien/pact-poc/lib/health"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/outbox"
 	"github.com/pact-foundation/pact-go/dsl"
 	"github.com/pact-foundation/pact-go/types"
 	"github.com/pact-foundation/pact-go/utils"
@@ -25,41 +28,57 @@ const (
 
 var (
 	configuration   config.Configuration
+	logger          logging.Logger
 	pactBrokerUrl   string
 	pactBrokerToken string
 	port            int
 	repository      *inmemorypers.UserRepository
 	eventBus        *inmemoryevb.EventBus
+	relay           *outbox.Relay
 	useCase         usecase.UserUseCase
 	server          *Server
 )
 
-func init() {
+func startServer(t *testing.T) {
 	configuration = environment.NewConfiguration()
-	pactBrokerUrl = configuration.GetStringOrCrash(pactBrokerUrlPropertyName)
-	pactBrokerToken = configuration.GetStringOrCrash(pactBrokerTokenPropertyName)
+	pactBrokerUrl = mandatory(t, pactBrokerUrlPropertyName)
+	pactBrokerToken = mandatory(t, pactBrokerTokenPropertyName)
 
 	var err error
 	port, err = utils.GetFreePort()
 	if err != nil {
-		log.Fatalf("could not find free port: %v", err)
+		t.Fatalf("could not find free port: %v", err)
 	}
 
 	if err := os.Setenv("PORT", strconv.Itoa(port)); err != nil {
-		log.Fatalf("could not set port environment variable: %v", err)
+		t.Fatalf("could not set port environment variable: %v", err)
 	}
 
-	repository = inmemorypers.NewUserRepository()
-	eventBus = inmemoryevb.NewEventBus()
-	useCase = usecase.NewUserUseCase(repository, eventBus)
-	server = NewServer(useCase)
+	logger = logging.NewLogger(configuration)
+	repository = inmemorypers.NewUserRepository(logger)
+	eventBus = inmemoryevb.NewEventBus(logger)
+	relay = outbox.NewRelay(configuration, repository, eventBus, logger)
+	useCase = usecase.NewUserUseCase(repository, relay, logger)
+	server = NewServer(configuration, useCase, relay, health.NewHealth(configuration, logger).
+		Add("repository", repository).
+		Add("eventbus", eventBus), logger)
 
 	go func() {
-		log.Println(server.Start())
+		log.Println(server.Start(context.Background()))
 	}()
 }
 
+func mandatory(t *testing.T, name string) string {
+	return configuration.GetString(name, func() string {
+		t.Fatalf("missing mandatory property: %s", name)
+		return ""
+	})
+}
+
 func TestServerHTTPPact(t *testing.T) {
+	startServer(t)
+	defer server.Stop(context.Background())
+
 	pact := dsl.Pact{
 		Provider:                 "user-server-http",
 		LogDir:                   "../../../../tests/pact/logs",
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
+	"github.com/frederic-gendebien/pact-poc/lib/tracing"
+)
+
+func NewTracedUserUseCase(useCase UserUseCase) *TracedUserUseCase {
+	return &TracedUserUseCase{
+		useCase: useCase,
+	}
+}
+
+type TracedUserUseCase struct {
+	useCase UserUseCase
+}
+
+func (t *TracedUserUseCase) RegisterNewUser(ctx context.Context, newUser model.User) (err error) {
+	ctx, span := tracing.Start(ctx, "usecase RegisterNewUser")
+	defer func() { tracing.End(span, err) }()
+
+	return t.useCase.RegisterNewUser(ctx, newUser)
+}
+
+func (t *TracedUserUseCase) CorrectUserDetails(ctx context.Context, userId model.UserId, newDetails model.UserDetails) (err error) {
+	ctx, span := tracing.Start(ctx, "usecase CorrectUserDetails")
+	defer func() { tracing.End(span, err) }()
+
+	return t.useCase.CorrectUserDetails(ctx, userId, newDetails)
+}
+
+func (t *TracedUserUseCase) DeleteUser(ctx context.Context, userId model.UserId) (err error) {
+	ctx, span := tracing.Start(ctx, "usecase DeleteUser")
+	defer func() { tracing.End(span, err) }()
+
+	return t.useCase.DeleteUser(ctx, userId)
+}
+
+func (t *TracedUserUseCase) ListUsers(ctx context.Context, cursor model.Cursor, limit int) (page model.UserPage, err error) {
+	ctx, span := tracing.Start(ctx, "usecase ListUsers")
+	defer func() { tracing.End(span, err) }()
+
+	return t.useCase.ListUsers(ctx, cursor, limit)
+}
+
+func (t *TracedUserUseCase) ListAllUsers(ctx context.Context) model.UserIterator {
+	return t.useCase.ListAllUsers(ctx)
+}
+
+func (t *TracedUserUseCase) FindUserById(ctx context.Context, userId model.UserId) (user model.User, err error) {
+	ctx, span := tracing.Start(ctx, "usecase FindUserById")
+	defer func() { tracing.End(span, err) }()
+
+	return t.useCase.FindUserById(ctx, userId)
+}
+
+func (t *TracedUserUseCase) GetUserHistory(ctx context.Context, userId model.UserId) (entries []model.UserHistoryEntry, err error) {
+	ctx, span := tracing.Start(ctx, "usecase GetUserHistory")
+	defer func() { tracing.End(span, err) }()
+
+	return t.useCase.GetUserHistory(ctx, userId)
+}
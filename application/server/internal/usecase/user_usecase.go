@@ -6,36 +6,45 @@ import (
 	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/events"
 	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
 	"github.com/frederic-gendebien/pact-poc/lib/eventbus"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/outbox"
 )
 
 type UserUseCase interface {
 	RegisterNewUser(ctx context.Context, newUser model.User) error
 	CorrectUserDetails(ctx context.Context, userId model.UserId, newDetails model.UserDetails) error
 	DeleteUser(ctx context.Context, userId model.UserId) error
-	ListAllUsers(ctx context.Context, next <-chan bool) (<-chan model.User, error)
+	ListUsers(ctx context.Context, cursor model.Cursor, limit int) (model.UserPage, error)
+	ListAllUsers(ctx context.Context) model.UserIterator
 	FindUserById(ctx context.Context, userId model.UserId) (model.User, error)
+	GetUserHistory(ctx context.Context, userId model.UserId) ([]model.UserHistoryEntry, error)
 }
 
-func NewUserUseCase(repository repository.UserRepository, eventBus eventbus.EventBus) *DefaultUserUseCase {
+func NewUserUseCase(repository repository.UserRepository, notifier outbox.Notifier, logger logging.Logger) *DefaultUserUseCase {
 	return &DefaultUserUseCase{
 		repository: repository,
-		eventBus:   eventBus,
+		outbox:     notifier,
+		logger:     logger,
 	}
 }
 
 type DefaultUserUseCase struct {
 	repository repository.UserRepository
-	eventBus   eventbus.EventBus
+	outbox     outbox.Notifier
+	logger     logging.Logger
 }
 
 func (d *DefaultUserUseCase) RegisterNewUser(ctx context.Context, newUser model.User) error {
-	if err := d.repository.AddUser(ctx, newUser); err != nil {
+	if err := d.repository.AddUser(ctx, newUser, eventbus.NewEnvelope(ctx, events.NewUserRegistered{
+		User: newUser,
+	})); err != nil {
 		return err
 	}
 
-	return d.eventBus.Publish(ctx, events.NewUserRegistered{
-		User: newUser,
-	})
+	d.logger.WithContext(ctx).With(logging.UserId, newUser.Id).Infof("registered new user")
+	d.outbox.Notify()
+
+	return nil
 }
 
 func (d *DefaultUserUseCase) CorrectUserDetails(ctx context.Context, userId model.UserId, newDetails model.UserDetails) error {
@@ -43,30 +52,67 @@ func (d *DefaultUserUseCase) CorrectUserDetails(ctx context.Context, userId mode
 		return user.CorrectDetails(newDetails)
 	}
 
-	if err := d.repository.UpdateUser(ctx, userId, correctDetails); err != nil {
+	if err := d.repository.UpdateUser(ctx, userId, correctDetails, eventbus.NewEnvelope(ctx, events.UserDetailsCorrected{
+		UserId:         userId,
+		NewUserDetails: newDetails,
+	})); err != nil {
 		return err
 	}
 
-	return d.eventBus.Publish(ctx, events.UserDetailsCorrected{
-		UserId:         userId,
-		NewUserDetails: newDetails,
-	})
+	d.logger.WithContext(ctx).With(logging.UserId, userId).Infof("corrected user details")
+	d.outbox.Notify()
+
+	return nil
 }
 
 func (d *DefaultUserUseCase) DeleteUser(ctx context.Context, userId model.UserId) error {
-	if err := d.repository.DeleteUser(ctx, userId); err != nil {
+	if err := d.repository.DeleteUser(ctx, userId, eventbus.NewEnvelope(ctx, events.UserDeleted{
+		UserId: userId,
+	})); err != nil {
 		return err
 	}
 
-	return d.eventBus.Publish(ctx, events.UserDeleted{
-		UserId: userId,
-	})
+	d.logger.WithContext(ctx).With(logging.UserId, userId).Infof("deleted user")
+	d.outbox.Notify()
+
+	return nil
+}
+
+func (d *DefaultUserUseCase) ListUsers(ctx context.Context, cursor model.Cursor, limit int) (model.UserPage, error) {
+	after, err := cursor.After()
+	if err != nil {
+		return model.UserPage{}, err
+	}
+
+	users, err := d.repository.ListUsers(ctx, after, limit+1)
+	if err != nil {
+		return model.UserPage{}, err
+	}
+
+	if len(users) <= limit {
+		return model.UserPage{Users: users}, nil
+	}
+
+	users = users[:limit]
+
+	return model.UserPage{
+		Users: users,
+		Next:  model.NewCursor(users[len(users)-1].Id),
+	}, nil
 }
 
-func (d *DefaultUserUseCase) ListAllUsers(ctx context.Context, next <-chan bool) (<-chan model.User, error) {
-	return d.repository.ListAllUsers(ctx, next)
+func (d *DefaultUserUseCase) ListAllUsers(ctx context.Context) model.UserIterator {
+	return d.repository.ListAllUsers(ctx)
 }
 
 func (d *DefaultUserUseCase) FindUserById(ctx context.Context, userId model.UserId) (model.User, error) {
 	return d.repository.GetUser(ctx, userId)
 }
+
+func (d *DefaultUserUseCase) GetUserHistory(ctx context.Context, userId model.UserId) ([]model.UserHistoryEntry, error) {
+	if history, ok := d.repository.(repository.UserHistoryRepository); ok {
+		return history.GetUserHistory(ctx, userId)
+	}
+
+	return nil, model.NewBadRequest("user history is not available with the current persistence mode")
+}
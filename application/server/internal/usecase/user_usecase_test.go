@@ -10,6 +10,8 @@ import (
 	"github.com/frederic-gendebien/pact-poc/lib/config/environment"
 	"github.com/frederic-gendebien/pact-poc/lib/eventbus"
 	inmemoryevb "github.com/frederic-gendebien/pact-poc/lib/eventbus/inmemory"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/outbox"
 	"github.com/pact-foundation/pact-go/dsl"
 	"github.com/pact-foundation/pact-go/types"
 	"testing"
@@ -22,10 +24,12 @@ const (
 
 var (
 	configuration   config.Configuration
+	logger          logging.Logger
 	pactBrokerUrl   string
 	pactBrokerToken string
 	repo            *inmemorypers.UserRepository
 	eventBus        *inmemoryevb.EventBus
+	relay           *outbox.Relay
 	eventSniffer    *eventbus.EventSniffer
 	useCase         UserUseCase
 )
@@ -35,10 +39,12 @@ func init() {
 	pactBrokerUrl = configuration.GetStringOrCrash(pactBrokerUrlPropertyName)
 	pactBrokerToken = configuration.GetStringOrCrash(pactBrokerTokenPropertyName)
 
-	repo = inmemorypers.NewUserRepository()
-	eventBus = inmemoryevb.NewEventBus()
-	eventSniffer = eventbus.NewEventSniffer(eventBus)
-	useCase = NewUserUseCase(repo, eventBus)
+	logger = logging.NewLogger(configuration)
+	repo = inmemorypers.NewUserRepository(logger)
+	eventBus = inmemoryevb.NewEventBus(logger)
+	eventSniffer = eventbus.NewEventSniffer(eventBus, logger)
+	relay = outbox.NewRelay(configuration, repo, eventBus, logger)
+	useCase = NewUserUseCase(repo, relay, logger)
 }
 
 func TestServerMessagePact(t *testing.T) {
@@ -78,14 +84,22 @@ func TestServerMessagePact(t *testing.T) {
 func messageHandlers() dsl.MessageHandlers {
 	return dsl.MessageHandlers{
 		"a user1 registered event": func(message dsl.Message) (interface{}, error) {
-			return eventSniffer.GetAndClearEvents()[0], nil
+			return publishedEvent()
 		},
 		"a user1 details corrected event": func(message dsl.Message) (interface{}, error) {
-			return eventSniffer.GetAndClearEvents()[0], nil
+			return publishedEvent()
 		},
 	}
 }
 
+func publishedEvent() (interface{}, error) {
+	if err := relay.Flush(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return eventSniffer.GetAndClearEvents()[0], nil
+}
+
 func messageStateHandlers() dsl.StateHandlers {
 	return dsl.StateHandlers{
 		"user1 has been registered": func(state dsl.State) error {
@@ -1,5 +1,17 @@
 package events
 
+import (
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+)
+
 const (
 	Domain string = "user"
 )
+
+func All() []domain.EventDefinition {
+	return []domain.EventDefinition{
+		NewUserRegistered{},
+		UserDetailsCorrected{},
+		UserDeleted{},
+	}
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"context"
+)
+
+type UserIterator interface {
+	Next() bool
+	User() User
+	Err() error
+	Close() error
+}
+
+type UserBatchFetcher func(ctx context.Context) (users []User, more bool, err error)
+
+func PagedFetcher(batchSize int, list func(ctx context.Context, after UserId, limit int) ([]User, error)) UserBatchFetcher {
+	after := UserId("")
+	return func(ctx context.Context) ([]User, bool, error) {
+		users, err := list(ctx, after, batchSize)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if len(users) > 0 {
+			after = users[len(users)-1].Id
+		}
+
+		return users, len(users) >= batchSize, nil
+	}
+}
+
+func NewUserIterator(ctx context.Context, fetch UserBatchFetcher) UserIterator {
+	return &batchUserIterator{
+		ctx:   ctx,
+		fetch: fetch,
+		more:  true,
+	}
+}
+
+type batchUserIterator struct {
+	ctx     context.Context
+	fetch   UserBatchFetcher
+	batch   []User
+	index   int
+	current User
+	more    bool
+	err     error
+	closed  bool
+}
+
+func (i *batchUserIterator) Next() bool {
+	if i.closed || i.err != nil {
+		return false
+	}
+
+	if err := i.ctx.Err(); err != nil {
+		i.err = err
+		return false
+	}
+
+	for i.index >= len(i.batch) {
+		if !i.more {
+			return false
+		}
+
+		batch, more, err := i.fetch(i.ctx)
+		if err != nil {
+			i.err = err
+			return false
+		}
+
+		i.batch, i.index, i.more = batch, 0, more
+	}
+
+	i.current = i.batch[i.index]
+	i.index++
+
+	return true
+}
+
+func (i *batchUserIterator) User() User {
+	return i.current
+}
+
+func (i *batchUserIterator) Err() error {
+	return i.err
+}
+
+func (i *batchUserIterator) Close() error {
+	i.closed = true
+	i.batch = nil
+
+	return nil
+}
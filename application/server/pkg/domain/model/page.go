@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+const (
+	cursorPrefix = "after:"
+)
+
+type Cursor string
+
+func NewCursor(lastUserId UserId) Cursor {
+	return Cursor(base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + string(lastUserId))))
+}
+
+func (c Cursor) IsStart() bool {
+	return c == ""
+}
+
+func (c Cursor) After() (UserId, error) {
+	if c.IsStart() {
+		return "", nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(string(c))
+	if err != nil || !strings.HasPrefix(string(decoded), cursorPrefix) {
+		return "", NewBadRequest("invalid cursor")
+	}
+
+	return UserId(strings.TrimPrefix(string(decoded), cursorPrefix)), nil
+}
+
+type UserPage struct {
+	Users []User `json:"users"`
+	Next  Cursor `json:"next,omitempty"`
+}
+
+func (p UserPage) HasNext() bool {
+	return p.Next != ""
+}
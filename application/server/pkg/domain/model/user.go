@@ -1,5 +1,9 @@
 package model
 
+import (
+	"time"
+)
+
 type UserId string
 
 func (i UserId) IsInvalid() bool {
@@ -68,3 +72,12 @@ func (d UserDetails) InvalidAfter(err error) error {
 
 	return d.Invalid()
 }
+
+type UserHistoryEntry struct {
+	Version       int         `json:"version"`
+	EventId       string      `json:"event_id"`
+	EventName     string      `json:"event_name"`
+	OccurredAt    time.Time   `json:"occurred_at"`
+	CorrelationId string      `json:"correlation_id"`
+	Event         interface{} `json:"event"`
+}
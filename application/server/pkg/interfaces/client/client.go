@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
 	"github.com/go-resty/resty/v2"
+	"strconv"
 )
 
 func NewClient(url string) *Client {
@@ -16,7 +17,13 @@ func NewClient(url string) *Client {
 }
 
 type Client struct {
-	client *resty.Client
+	client   *resty.Client
+	pageSize int
+}
+
+func (c *Client) WithPageSize(pageSize int) *Client {
+	c.pageSize = pageSize
+	return c
 }
 
 func (c *Client) Close() error {
@@ -66,35 +73,65 @@ func (c *Client) DeleteUser(ctx context.Context, userId model.UserId) error {
 	return err
 }
 
-func (c *Client) ListAllUsers(ctx context.Context, next <-chan bool) (<-chan model.User, error) {
-	response, err := c.client.R().
-		Get("/users")
+func (c *Client) ListUsers(ctx context.Context, cursor model.Cursor, limit int) (model.UserPage, error) {
+	page, err := c.listUsers(c.usersRequest(cursor, limit).SetContext(ctx), "/users")
+
+	return page.UserPage, err
+}
+
+func (c *Client) ListAllUsers(ctx context.Context) model.UserIterator {
+	nextLink := ""
+	return model.NewUserIterator(ctx, func(ctx context.Context) ([]model.User, bool, error) {
+		request := c.usersRequest("", c.pageSize)
+		url := "/users"
+		if nextLink != "" {
+			request, url = c.client.R(), nextLink
+		}
+
+		page, err := c.listUsers(request.SetContext(ctx), url)
+		if err != nil {
+			return nil, false, err
+		}
+
+		nextLink = page.nextLink
 
+		return page.Users, nextLink != "", nil
+	})
+}
+
+func (c *Client) usersRequest(cursor model.Cursor, limit int) *resty.Request {
+	request := c.client.R()
+	if !cursor.IsStart() {
+		request.SetQueryParam("after", string(cursor))
+	}
+
+	if limit > 0 {
+		request.SetQueryParam("limit", strconv.Itoa(limit))
+	}
+
+	return request
+}
+
+func (c *Client) listUsers(request *resty.Request, url string) (linkedPage, error) {
+	response, err := request.Get(url)
 	if err != nil {
-		return nil, model.NewUnknownError("could not list all users", err)
+		return linkedPage{}, model.NewUnknownError("could not list all users", err)
 	}
 
 	users, err := bodyOrError(response, usersProvider())
 	if err != nil {
-		return nil, err
+		return linkedPage{}, err
 	}
 
-	results := make(chan model.User)
-	go func() {
-		defer close(results)
-
-		for _, user := range users.([]model.User) {
-			results <- user
-			select {
-			case needNext := <-next:
-				if !needNext {
-					break
-				}
-			}
-		}
-	}()
+	nextLink := nextLinkFrom(response)
 
-	return results, nil
+	return linkedPage{
+		UserPage: model.UserPage{
+			Users: users.([]model.User),
+			Next:  cursorFrom(nextLink),
+		},
+		nextLink: nextLink,
+	}, nil
 }
 
 func (c *Client) FindUserById(ctx context.Context, userId model.UserId) (model.User, error) {
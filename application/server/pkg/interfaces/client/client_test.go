@@ -214,18 +214,16 @@ func TestClientPact_ListAllUsers(t *testing.T) {
 			})
 
 		verify(t, pact, func() error {
-			next := make(chan bool)
-			defer close(next)
+			users := userClient.ListAllUsers(context.Background())
+			defer users.Close()
 
-			users, err := userClient.ListAllUsers(context.Background(), next)
-			if err != nil {
-				return fmt.Errorf("could not list all users: %v", err)
+			userList := make([]model.User, 0, 5)
+			for users.Next() {
+				userList = append(userList, users.User())
 			}
 
-			userList := make([]model.User, 0, 5)
-			for user := range users {
-				userList = append(userList, user)
-				next <- true
+			if err := users.Err(); err != nil {
+				return fmt.Errorf("could not list all users: %v", err)
 			}
 
 			if !reflect.DeepEqual(userList, expectedUsers) {
@@ -255,18 +253,53 @@ func TestClientPact_ListAllUsers(t *testing.T) {
 			})
 
 		verify(t, pact, func() error {
-			next := make(chan bool)
-			defer close(next)
+			users := userClient.ListAllUsers(context.Background())
+			defer users.Close()
 
-			users, err := userClient.ListAllUsers(context.Background(), next)
-			if err != nil {
+			userList := make([]model.User, 0, 5)
+			for users.Next() {
+				userList = append(userList, users.User())
+			}
+
+			if err := users.Err(); err != nil {
 				return fmt.Errorf("could not list all users: %v", err)
 			}
 
+			if !reflect.DeepEqual(userList, expectedUsers) {
+				return fmt.Errorf("expected: %v, but got %v", expectedUsers, userList)
+			}
+
+			return nil
+		})
+	})
+}
+
+func TestClientPact_ListUsersPageByPage(t *testing.T) {
+	t.Run("List All Users Following Pages", func(t *testing.T) {
+		pact.Interactions = nil
+		expectedUsers := []model.User{
+			testUser(1),
+			testUser(2),
+			testUser(3),
+			testUser(4),
+			testUser(5),
+		}
+		addUsersPageInteraction("A list users first page request", "", expectedUsers[0:2], model.NewCursor("user2"))
+		addUsersPageInteraction("A list users second page request", model.NewCursor("user2"), expectedUsers[2:4], model.NewCursor("user4"))
+		addUsersPageInteraction("A list users last page request", model.NewCursor("user4"), expectedUsers[4:], "")
+
+		verify(t, pact, func() error {
+			pagedClient := NewClient(fmt.Sprintf("http://localhost:%d", pact.Server.Port)).WithPageSize(2)
+			users := pagedClient.ListAllUsers(context.Background())
+			defer users.Close()
+
 			userList := make([]model.User, 0, 5)
-			for user := range users {
-				userList = append(userList, user)
-				next <- true
+			for users.Next() {
+				userList = append(userList, users.User())
+			}
+
+			if err := users.Err(); err != nil {
+				return fmt.Errorf("could not list all users: %v", err)
 			}
 
 			if !reflect.DeepEqual(userList, expectedUsers) {
@@ -276,6 +309,60 @@ func TestClientPact_ListAllUsers(t *testing.T) {
 			return nil
 		})
 	})
+	t.Run("List A Users Page", func(t *testing.T) {
+		pact.Interactions = nil
+		expectedPage := model.UserPage{
+			Users: []model.User{testUser(3), testUser(4)},
+			Next:  model.NewCursor("user4"),
+		}
+		addUsersPageInteraction("A list users second page request", model.NewCursor("user2"), expectedPage.Users, expectedPage.Next)
+
+		verify(t, pact, func() error {
+			page, err := userClient.ListUsers(context.Background(), model.NewCursor("user2"), 2)
+			if err != nil {
+				return fmt.Errorf("could not list users page: %v", err)
+			}
+
+			if !reflect.DeepEqual(page, expectedPage) {
+				return fmt.Errorf("expected: %v, but got %v", expectedPage, page)
+			}
+
+			return nil
+		})
+	})
+}
+
+func addUsersPageInteraction(description string, after model.Cursor, users []model.User, next model.Cursor) {
+	query := dsl.MapMatcher{
+		"limit": dsl.String("2"),
+	}
+	if after != "" {
+		query["after"] = dsl.Term(string(after), `^[A-Za-z0-9_-]+$`)
+	}
+
+	headers := responseHeadersWithBody()
+	if next != "" {
+		headers["Link"] = dsl.Term(
+			fmt.Sprintf(`</users?after=%s&limit=2>; rel="next"`, next),
+			`^<\/users\?after=[A-Za-z0-9_-]+&limit=2>; rel="next"$`,
+		)
+	}
+
+	pact.AddInteraction().
+		Given("Many users exist").
+		UponReceiving(description).
+		WithRequest(dsl.Request{
+			Method:  gohttp.MethodGet,
+			Path:    dsl.Term("/users", "^/users$"),
+			Query:   query,
+			Headers: requestHeadersWithoutBody(),
+			Body:    nil,
+		}).
+		WillRespondWith(dsl.Response{
+			Status:  gohttp.StatusOK,
+			Headers: headers,
+			Body:    users,
+		})
 }
 
 func TestClientPact_FindUserById(t *testing.T) {
@@ -7,6 +7,8 @@ import (
 	"github.com/frederic-gendebien/pact-poc/application/server/pkg/domain/model"
 	"github.com/go-resty/resty/v2"
 	gohttp "net/http"
+	"net/url"
+	"regexp"
 )
 
 func bodyOrError(response *resty.Response, provider func([]byte) (interface{}, error)) (interface{}, error) {
@@ -64,3 +66,33 @@ func errorMessage(response *resty.Response) string {
 
 	return errorResponse.Message
 }
+
+var (
+	nextLinkPattern = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="next"`)
+)
+
+type linkedPage struct {
+	model.UserPage
+	nextLink string
+}
+
+func nextLinkFrom(response *resty.Response) string {
+	if match := nextLinkPattern.FindStringSubmatch(response.Header().Get("Link")); match != nil {
+		return match[1]
+	}
+
+	return ""
+}
+
+func cursorFrom(link string) model.Cursor {
+	if link == "" {
+		return ""
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	return model.Cursor(parsed.Query().Get("after"))
+}
@@ -1,33 +1,180 @@
 package config
 
 import (
+	"fmt"
 	"github.com/frederic-gendebien/pact-poc/lib/config/environment"
+	"github.com/frederic-gendebien/pact-poc/lib/config/layered"
+	"github.com/frederic-gendebien/pact-poc/lib/config/secret"
 	"io"
 	"log"
+	"net/url"
 	"os"
+	"strings"
+	"text/tabwriter"
 )
 
 const (
 	Mode            = "CONFIGURATION_MODE"
 	ModeEnvironment = "environment"
+	ModeFile        = "file"
+	ModeLayered     = "layered"
+	File            = "CONFIGURATION_FILE"
+	Print           = "CONFIGURATION_PRINT"
+	masked          = "******"
+)
+
+var (
+	secretMarkers = []string{"PASSWORD", "SECRET", "TOKEN", "CREDENTIAL", "PRIVATE_KEY", "API_KEY"}
 )
 
 func NewConfiguration() Configuration {
-	mode := os.Getenv("CONFIGURATION_MODE")
+	mode := os.Getenv(Mode)
 	switch mode {
 	case "":
 		log.Println("default configuration mode")
 		fallthrough
 	case ModeEnvironment:
 		return environment.NewConfiguration()
+	case ModeFile:
+		log.Printf("starting file configuration: %s", mandatoryFile())
+		return validated(layered.NewConfiguration(withSecrets(fileLayer(mandatoryFile()))...))
+	case ModeLayered:
+		log.Println("starting layered configuration")
+		return newLayeredConfiguration(os.Getenv(File), os.Args[1:])
 	default:
 		log.Fatalf("unknown configuration mode: %s", mode)
 		return nil
 	}
 }
 
+func newLayeredConfiguration(path string, args []string) *layered.Configuration {
+	layers := make([]layered.Layer, 0, 3)
+	if path != "" {
+		layers = append(layers, fileLayer(path))
+	}
+
+	flags, err := layered.NewFlagLayer(args)
+	if err != nil {
+		log.Fatalf("invalid command-line configuration: %v", err)
+	}
+
+	return validated(layered.NewConfiguration(withSecrets(append(layers, layered.NewEnvironmentLayer(), flags)...)...))
+}
+
+func validated(configuration *layered.Configuration) *layered.Configuration {
+	return configuration.ValidateWith(func(view layered.View) error {
+		return Validate(view)
+	})
+}
+
+func withSecrets(layers ...layered.Layer) []layered.Layer {
+	providers := secret.DefaultProviders()
+	resolving := make([]layered.Layer, len(layers))
+	for i, layer := range layers {
+		resolving[i] = secret.NewLayer(layer, providers...)
+	}
+
+	return resolving
+}
+
+func mandatoryFile() string {
+	path := os.Getenv(File)
+	if path == "" {
+		log.Fatalf("missing mandatory property: %s", File)
+	}
+
+	return path
+}
+
+func fileLayer(path string) layered.Layer {
+	layer, err := layered.NewFileLayer(path)
+	if err != nil {
+		log.Fatalf("could not load configuration: %v", err)
+	}
+
+	return layer
+}
+
 type Configuration interface {
 	io.Closer
 	GetString(name string, defaultProvider func() string) string
 	GetStringOrCrash(name string) string
 }
+
+type Describer interface {
+	Entries() []layered.Entry
+	Source(name string) (string, bool)
+}
+
+func Entries(configuration Configuration) []layered.Entry {
+	describer, ok := configuration.(Describer)
+	if !ok {
+		return nil
+	}
+
+	entries := describer.Entries()
+	for i := range entries {
+		entries[i].Value = MaskSensitive(entries[i].Name, entries[i].Value, entries[i].Sensitive)
+	}
+
+	return entries
+}
+
+func PrintEffectiveIfRequested(configuration Configuration, writer io.Writer) error {
+	requested := configuration.GetString(Print, func() string {
+		if value := os.Getenv(Print); value != "" {
+			return value
+		}
+
+		return "false"
+	})
+	if requested != "true" {
+		return nil
+	}
+
+	return PrintEffective(configuration, writer)
+}
+
+func PrintEffective(configuration Configuration, writer io.Writer) error {
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(table, "NAME\tVALUE\tSOURCE"); err != nil {
+		return err
+	}
+
+	for _, entry := range Entries(configuration) {
+		if _, err := fmt.Fprintf(table, "%s\t%s\t%s\n", entry.Name, entry.Value, entry.Source); err != nil {
+			return err
+		}
+	}
+
+	return table.Flush()
+}
+
+func MaskSensitive(name string, value string, sensitive bool) string {
+	if sensitive && value != "" {
+		return masked
+	}
+
+	return Mask(name, value)
+}
+
+func Mask(name string, value string) string {
+	if value == "" {
+		return value
+	}
+
+	upperName := strings.ToUpper(name)
+	for _, marker := range secretMarkers {
+		if strings.Contains(upperName, marker) {
+			return masked
+		}
+	}
+
+	if parsed, err := url.Parse(value); err == nil && parsed.User != nil {
+		if _, hasPassword := parsed.User.Password(); hasPassword {
+			return strings.Replace(parsed.Redacted(), ":xxxxx@", ":"+masked+"@", 1)
+		}
+	}
+
+	return value
+}
@@ -1,18 +1,20 @@
 package environment
 
 import (
-	"github.com/joho/godotenv"
+	"github.com/frederic-gendebien/pact-poc/lib/config/layered"
+	"github.com/frederic-gendebien/pact-poc/lib/config/secret"
 	"log"
-	"os"
 )
 
 func NewConfiguration() Configuration {
 	log.Println("starting inmemory configuration")
-	_ = godotenv.Load()
-	return Configuration{}
+	return Configuration{
+		Configuration: layered.NewConfiguration(secret.NewLayer(layered.NewEnvironmentLayer(), secret.DefaultProviders()...)),
+	}
 }
 
 type Configuration struct {
+	*layered.Configuration
 }
 
 func (c Configuration) Close() error {
@@ -20,19 +22,3 @@ func (c Configuration) Close() error {
 
 	return nil
 }
-
-func (c Configuration) GetString(name string, defaultProvider func() string) string {
-	value := os.Getenv(name)
-	if value == "" {
-		return defaultProvider()
-	}
-
-	return value
-}
-
-func (c Configuration) GetStringOrCrash(name string) string {
-	return c.GetString(name, func() string {
-		log.Fatalf("missing mandatory property: %s", name)
-		return ""
-	})
-}
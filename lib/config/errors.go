@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+func NewMissingPropertyError(name string) PropertyError {
+	return PropertyError{
+		Name:   name,
+		Reason: "is missing",
+	}
+}
+
+func NewInvalidPropertyError(name string, propertyType Type, cause error) PropertyError {
+	return PropertyError{
+		Name:   name,
+		Reason: fmt.Sprintf("is not a valid %s: %v", propertyType, cause),
+	}
+}
+
+func NewUnresolvedPropertyError(name string, cause error) PropertyError {
+	return PropertyError{
+		Name:   name,
+		Reason: fmt.Sprintf("could not be resolved: %v", cause),
+	}
+}
+
+func NewUnsupportedPropertyError(name string, allowed []string) PropertyError {
+	return PropertyError{
+		Name:   name,
+		Reason: fmt.Sprintf("must be one of [%s]", strings.Join(allowed, ", ")),
+	}
+}
+
+type PropertyError struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+func (p PropertyError) Error() string {
+	return fmt.Sprintf("property %s %s", p.Name, p.Reason)
+}
+
+func (p PropertyError) Is(err error) bool {
+	_, ok := err.(PropertyError)
+
+	return ok
+}
+
+type ValidationError struct {
+	Problems []PropertyError `json:"problems"`
+}
+
+func (v ValidationError) Error() string {
+	problems := make([]string, len(v.Problems))
+	for i, problem := range v.Problems {
+		problems[i] = problem.Error()
+	}
+
+	return fmt.Sprintf("%d invalid configuration properties: %s", len(v.Problems), strings.Join(problems, "; "))
+}
+
+func (v ValidationError) Is(err error) bool {
+	_, ok := err.(ValidationError)
+
+	return ok
+}
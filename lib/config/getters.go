@@ -0,0 +1,192 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String resolves name, then each fallback in turn, before falling back to
+// the default registered in the schema for the first of them that has one.
+func String(configuration Configuration, name string, fallbacks ...string) string {
+	names := append([]string{name}, fallbacks...)
+
+	return resolve(configuration, names, defaultFor(names))
+}
+
+func MandatoryString(configuration Configuration, name string, fallbacks ...string) (string, error) {
+	value := String(configuration, name, fallbacks...)
+	if err := failureOfAny(configuration, append([]string{name}, fallbacks...)); err != nil {
+		return "", NewUnresolvedPropertyError(name, err)
+	}
+
+	if value == "" {
+		return "", NewMissingPropertyError(name)
+	}
+
+	return value, nil
+}
+
+func Int(configuration Configuration, name string, fallbacks ...string) (int, error) {
+	value, err := typed(configuration, TypeInt, name, fallbacks)
+	if err != nil || value == "" {
+		return 0, err
+	}
+
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, NewInvalidPropertyError(name, TypeInt, err)
+	}
+
+	return intValue, nil
+}
+
+func Float(configuration Configuration, name string, fallbacks ...string) (float64, error) {
+	value, err := typed(configuration, TypeFloat, name, fallbacks)
+	if err != nil || value == "" {
+		return 0, err
+	}
+
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, NewInvalidPropertyError(name, TypeFloat, err)
+	}
+
+	return floatValue, nil
+}
+
+func Bool(configuration Configuration, name string, fallbacks ...string) (bool, error) {
+	value, err := typed(configuration, TypeBool, name, fallbacks)
+	if err != nil || value == "" {
+		return false, err
+	}
+
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, NewInvalidPropertyError(name, TypeBool, err)
+	}
+
+	return boolValue, nil
+}
+
+func Duration(configuration Configuration, name string, fallbacks ...string) (time.Duration, error) {
+	value, err := typed(configuration, TypeDuration, name, fallbacks)
+	if err != nil || value == "" {
+		return 0, err
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, NewInvalidPropertyError(name, TypeDuration, err)
+	}
+
+	return duration, nil
+}
+
+func URL(configuration Configuration, name string, fallbacks ...string) (*url.URL, error) {
+	value, err := typed(configuration, TypeURL, name, fallbacks)
+	if err != nil || value == "" {
+		return nil, err
+	}
+
+	parsed, err := parseURL(value)
+	if err != nil {
+		return nil, NewInvalidPropertyError(name, TypeURL, err)
+	}
+
+	return parsed, nil
+}
+
+func List(configuration Configuration, name string, fallbacks ...string) ([]string, error) {
+	value, err := typed(configuration, TypeList, name, fallbacks)
+	if err != nil || value == "" {
+		return nil, err
+	}
+
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+func typed(configuration Configuration, propertyType Type, name string, fallbacks []string) (string, error) {
+	names := append([]string{name}, fallbacks...)
+	value := resolve(configuration, names, defaultFor(names))
+	if err := failureOfAny(configuration, names); err != nil {
+		return "", NewUnresolvedPropertyError(name, err)
+	}
+
+	if value == "" {
+		if isRequired(names) {
+			return "", NewMissingPropertyError(name)
+		}
+		return "", nil
+	}
+
+	if property, found := schema.Lookup(name); found && property.Type == propertyType {
+		if err := property.parse(value); err != nil {
+			return "", err
+		}
+	}
+
+	return value, nil
+}
+
+func resolve(configuration Configuration, names []string, defaultValue string) string {
+	if len(names) == 0 {
+		return defaultValue
+	}
+
+	return configuration.GetString(names[0], func() string {
+		return resolve(configuration, names[1:], defaultValue)
+	})
+}
+
+func failureOfAny(configuration Configuration, names []string) error {
+	for _, name := range names {
+		if err := failureOf(configuration, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func defaultFor(names []string) string {
+	for _, name := range names {
+		if property, found := schema.Lookup(name); found && property.Default != "" {
+			return property.Default
+		}
+	}
+
+	return ""
+}
+
+func isRequired(names []string) bool {
+	for _, name := range names {
+		if property, found := schema.Lookup(name); found && property.Required {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseURL(value string) (*url.URL, error) {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("malformed url")
+	}
+
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("missing scheme or host in %s", Mask("URL", value))
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,331 @@
+package layered
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+)
+
+const (
+	SourceDefault = "default"
+)
+
+type Layer interface {
+	Name() string
+	Lookup(name string) (string, bool)
+	Names() []string
+}
+
+type Reloader interface {
+	Modified() bool
+	Reload() error
+	Rollback()
+}
+
+// Resolver is implemented by layers resolving their values from references,
+// such as secrets, instead of holding them.
+type Resolver interface {
+	Sensitive(name string) bool
+	Failure(name string) error
+}
+
+type Entry struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	Source    string `json:"source"`
+	Sensitive bool   `json:"sensitive,omitempty"`
+}
+
+type Change struct {
+	Name      string `json:"name"`
+	Previous  string `json:"previous"`
+	Current   string `json:"current"`
+	Source    string `json:"source"`
+	Sensitive bool   `json:"sensitive,omitempty"`
+}
+
+func NewConfiguration(layers ...Layer) *Configuration {
+	return &Configuration{
+		layers:      layers,
+		reloading:   &sync.RWMutex{},
+		lock:        &sync.RWMutex{},
+		resolved:    make(map[string]Entry),
+		defaults:    make(map[string]func() string),
+		subscribers: make(map[int]func(changes []Change)),
+	}
+}
+
+type Configuration struct {
+	layers      []Layer
+	reloading   *sync.RWMutex
+	lock        *sync.RWMutex
+	resolved    map[string]Entry
+	defaults    map[string]func() string
+	subscribers map[int]func(changes []Change)
+	subscriber  int
+	validate    func(view View) error
+}
+
+// ValidateWith makes each reload check the reloaded values before they are
+// applied. A reload failing validation is rolled back.
+func (c *Configuration) ValidateWith(validate func(view View) error) *Configuration {
+	c.validate = validate
+
+	return c
+}
+
+func (c *Configuration) Close() error {
+	return nil
+}
+
+func (c *Configuration) GetString(name string, defaultProvider func() string) string {
+	c.reloading.RLock()
+	entry, found := c.lookup(name)
+	c.reloading.RUnlock()
+	if !found {
+		entry = Entry{
+			Name:   name,
+			Value:  defaultProvider(),
+			Source: SourceDefault,
+		}
+	}
+
+	c.lock.Lock()
+	c.resolved[name] = entry
+	c.defaults[name] = defaultProvider
+	c.lock.Unlock()
+
+	return entry.Value
+}
+
+// Subscribe registers a subscriber notified with the resolved values that
+// changed on each reload. The returned function cancels the subscription.
+func (c *Configuration) Subscribe(subscriber func(changes []Change)) func() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.subscriber++
+	id := c.subscriber
+	c.subscribers[id] = subscriber
+
+	return func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		delete(c.subscribers, id)
+	}
+}
+
+func (c *Configuration) Modified() bool {
+	for _, layer := range c.layers {
+		if reloader, ok := layer.(Reloader); ok && reloader.Modified() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reload re-reads the reloadable layers and resolves again every value read
+// so far, notifying the subscribers of the values that changed. Values are
+// only applied once the reloaded layers pass validation.
+func (c *Configuration) Reload() ([]Change, error) {
+	c.reloading.Lock()
+	err := c.reloadLayers()
+	c.reloading.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := c.resolveAgain()
+	if len(changes) > 0 {
+		c.notify(changes)
+	}
+
+	return changes, nil
+}
+
+func (c *Configuration) reloadLayers() error {
+	reloaded := make([]Reloader, 0, len(c.layers))
+	for _, layer := range c.layers {
+		if reloader, ok := layer.(Reloader); ok {
+			if err := reloader.Reload(); err != nil {
+				rollback(reloaded)
+				return err
+			}
+			reloaded = append(reloaded, reloader)
+		}
+	}
+
+	if c.validate != nil {
+		if err := c.validate(View{configuration: c}); err != nil {
+			rollback(reloaded)
+			return fmt.Errorf("reloaded configuration is invalid: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// resolveAgain runs once the layers are reloaded, outside of the reloading
+// lock, since default providers may fall back on other values.
+func (c *Configuration) resolveAgain() []Change {
+	c.lock.RLock()
+	previous := make(map[string]Entry, len(c.resolved))
+	defaults := make(map[string]func() string, len(c.defaults))
+	for name, entry := range c.resolved {
+		previous[name] = entry
+		defaults[name] = c.defaults[name]
+	}
+	c.lock.RUnlock()
+
+	changes := make([]Change, 0)
+	for name, entry := range previous {
+		c.reloading.RLock()
+		current, found := c.lookup(name)
+		c.reloading.RUnlock()
+		if !found {
+			current = Entry{
+				Name:   name,
+				Value:  defaults[name](),
+				Source: SourceDefault,
+			}
+		}
+
+		c.lock.Lock()
+		c.resolved[name] = current
+		c.lock.Unlock()
+
+		if current.Value != entry.Value {
+			changes = append(changes, Change{
+				Name:      name,
+				Previous:  entry.Value,
+				Current:   current.Value,
+				Source:    current.Source,
+				Sensitive: entry.Sensitive || current.Sensitive,
+			})
+		}
+	}
+	sort.Slice(changes, func(a, b int) bool {
+		return changes[a].Name < changes[b].Name
+	})
+
+	return changes
+}
+
+func rollback(reloaded []Reloader) {
+	for _, reloader := range reloaded {
+		reloader.Rollback()
+	}
+}
+
+func (c *Configuration) notify(changes []Change) {
+	c.lock.RLock()
+	subscribers := make([]func(changes []Change), 0, len(c.subscribers))
+	for _, subscriber := range c.subscribers {
+		subscribers = append(subscribers, subscriber)
+	}
+	c.lock.RUnlock()
+
+	for _, subscriber := range subscribers {
+		subscriber(changes)
+	}
+}
+
+func (c *Configuration) GetStringOrCrash(name string) string {
+	return c.GetString(name, func() string {
+		log.Fatalf("missing mandatory property: %s", name)
+		return ""
+	})
+}
+
+func (c *Configuration) Source(name string) (string, bool) {
+	c.reloading.RLock()
+	entry, found := c.lookup(name)
+	c.reloading.RUnlock()
+	if found {
+		return entry.Source, true
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	entry, resolved := c.resolved[name]
+	return entry.Source, resolved
+}
+
+func (c *Configuration) Entries() []Entry {
+	entries := make(map[string]Entry)
+	c.reloading.RLock()
+	for _, layer := range c.layers {
+		for _, name := range layer.Names() {
+			if entry, found := c.lookup(name); found {
+				entries[name] = entry
+			}
+		}
+	}
+	c.reloading.RUnlock()
+
+	c.lock.RLock()
+	for name, entry := range c.resolved {
+		if _, present := entries[name]; !present {
+			entries[name] = entry
+		}
+	}
+	c.lock.RUnlock()
+
+	sorted := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		sorted = append(sorted, entry)
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		return sorted[a].Name < sorted[b].Name
+	})
+
+	return sorted
+}
+
+func (c *Configuration) Failure(name string) error {
+	c.reloading.RLock()
+	defer c.reloading.RUnlock()
+
+	return c.failure(name)
+}
+
+func (c *Configuration) failure(name string) error {
+	if layer, found := c.layerOf(name); found {
+		if resolver, ok := layer.(Resolver); ok {
+			return resolver.Failure(name)
+		}
+	}
+
+	return nil
+}
+
+func (c *Configuration) lookup(name string) (Entry, bool) {
+	layer, found := c.layerOf(name)
+	if !found {
+		return Entry{}, false
+	}
+
+	value, _ := layer.Lookup(name)
+	resolver, resolved := layer.(Resolver)
+
+	return Entry{
+		Name:      name,
+		Value:     value,
+		Source:    layer.Name(),
+		Sensitive: resolved && resolver.Sensitive(name),
+	}, true
+}
+
+func (c *Configuration) layerOf(name string) (Layer, bool) {
+	for i := len(c.layers) - 1; i >= 0; i-- {
+		if _, found := c.layers[i].Lookup(name); found {
+			return c.layers[i], true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,238 @@
+package layered
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestConfiguration_Precedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+rabbitmq:
+  url: amqp://file
+  publish-channels: 4
+persistence_mode: bolt
+outbox:
+  batch_size: 10
+`)
+	file, err := NewFileLayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags, err := NewFlagLayer([]string{"--persistence-mode=inmemory", "--outbox.batch_size", "20", "--configuration-print"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("RABBITMQ_URL", "amqp://environment")
+	t.Setenv("OUTBOX_BATCH_SIZE", "15")
+	configuration := NewConfiguration(file, NewEnvironmentLayer(), flags)
+
+	expected := []Entry{
+		{Name: "CONFIGURATION_PRINT", Value: "true", Source: SourceFlag},
+		{Name: "LIFECYCLE_SHUTDOWN_TIMEOUT", Value: "30s", Source: SourceDefault},
+		{Name: "OUTBOX_BATCH_SIZE", Value: "20", Source: SourceFlag},
+		{Name: "PERSISTENCE_MODE", Value: "inmemory", Source: SourceFlag},
+		{Name: "RABBITMQ_PUBLISH_CHANNELS", Value: "4", Source: SourceFile},
+		{Name: "RABBITMQ_URL", Value: "amqp://environment", Source: SourceEnvironment},
+	}
+
+	configuration.GetString("LIFECYCLE_SHUTDOWN_TIMEOUT", func() string {
+		return "30s"
+	})
+	for _, entry := range expected {
+		if value := configuration.GetString(entry.Name, func() string { return "30s" }); value != entry.Value {
+			t.Fatalf("expected %s to be %s, but got: %s", entry.Name, entry.Value, value)
+		}
+
+		if source, _ := configuration.Source(entry.Name); source != entry.Source {
+			t.Fatalf("expected %s to come from %s, but got: %s", entry.Name, entry.Source, source)
+		}
+	}
+
+	if entries := configuration.Entries(); !reflect.DeepEqual(entries, expected) {
+		t.Fatalf("expected entries %v, but got: %v", expected, entries)
+	}
+}
+
+func TestNewFileLayer_Formats(t *testing.T) {
+	files := map[string]string{
+		"config.json": `{"rabbitmq": {"url": "amqp://json", "retry": {"max_attempts": 5}}, "tags": ["a", "b"]}`,
+		"config.toml": "tags = [\"a\", \"b\"]\n[rabbitmq]\nurl = \"amqp://toml\"\n[rabbitmq.retry]\nmax_attempts = 5\n",
+		"config.yml":  "rabbitmq:\n  url: amqp://yml\n  retry:\n    max_attempts: 5\ntags:\n  - a\n  - b\n",
+	}
+
+	for name, content := range files {
+		layer, err := NewFileLayer(writeFile(t, name, content))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		for key, value := range map[string]string{
+			"RABBITMQ_URL":                "amqp://" + filepath.Ext(name)[1:],
+			"RABBITMQ_RETRY_MAX_ATTEMPTS": "5",
+			"TAGS":                        "a,b",
+		} {
+			if actual, found := layer.Lookup(key); !found || actual != value {
+				t.Fatalf("%s: expected %s to be %s, but got: %s", name, key, value, actual)
+			}
+		}
+	}
+
+	if _, err := NewFileLayer(writeFile(t, "config.ini", "")); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
+
+func TestNewFlagLayer_RejectsPositionalArguments(t *testing.T) {
+	if _, err := NewFlagLayer([]string{"serve"}); err == nil {
+		t.Fatal("expected an error for a positional argument")
+	}
+}
+
+func TestConfiguration_Reload(t *testing.T) {
+	path := writeFile(t, "config.yaml", "log_level: info\nrabbitmq:\n  prefetch: 10\n")
+	file, err := NewFileLayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configuration := NewConfiguration(file)
+	configuration.GetString("LOG_LEVEL", func() string { return "warn" })
+	configuration.GetString("RABBITMQ_PREFETCH", func() string { return "0" })
+	configuration.GetString("RABBITMQ_PROJECTION_PREFETCH", func() string {
+		return configuration.GetString("RABBITMQ_PREFETCH", func() string { return "0" })
+	})
+
+	var notified []Change
+	unsubscribe := configuration.Subscribe(func(changes []Change) {
+		notified = changes
+	})
+	defer unsubscribe()
+
+	if configuration.Modified() {
+		t.Fatal("expected an untouched file not to be modified")
+	}
+
+	if err := os.WriteFile(path, []byte("rabbitmq:\n  prefetch: 20\n  projection:\n    prefetch: 5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if !configuration.Modified() {
+		t.Fatal("expected the rewritten file to be modified")
+	}
+
+	changes, err := configuration.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Change{
+		{Name: "LOG_LEVEL", Previous: "info", Current: "warn", Source: SourceDefault},
+		{Name: "RABBITMQ_PREFETCH", Previous: "10", Current: "20", Source: SourceFile},
+		{Name: "RABBITMQ_PROJECTION_PREFETCH", Previous: "10", Current: "5", Source: SourceFile},
+	}
+	if !reflect.DeepEqual(changes, expected) || !reflect.DeepEqual(notified, expected) {
+		t.Fatalf("expected changes %v, but got: %v (notified: %v)", expected, changes, notified)
+	}
+
+	if err := os.WriteFile(path, []byte("rabbitmq: [broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := configuration.Reload(); err == nil {
+		t.Fatal("expected a broken file not to be reloaded")
+	}
+
+	if value := configuration.GetString("RABBITMQ_PREFETCH", func() string { return "0" }); value != "20" {
+		t.Fatalf("expected the last valid value to be kept, but got: %s", value)
+	}
+}
+
+func TestConfiguration_ReloadFallback(t *testing.T) {
+	path := writeFile(t, "config.yaml", "rabbitmq:\n  projection:\n    prefetch: 5\n")
+	file, err := NewFileLayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configuration := NewConfiguration(file)
+	configuration.GetString("RABBITMQ_PROJECTION_PREFETCH", func() string {
+		return configuration.GetString("RABBITMQ_PREFETCH", func() string { return "0" })
+	})
+
+	if err := os.WriteFile(path, []byte("rabbitmq:\n  prefetch: 20\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := configuration.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Change{
+		{Name: "RABBITMQ_PROJECTION_PREFETCH", Previous: "5", Current: "20", Source: SourceDefault},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("expected the removed value to fall back on the shared one %v, but got: %v", expected, changes)
+	}
+}
+
+func TestConfiguration_ReloadInvalid(t *testing.T) {
+	path := writeFile(t, "config.yaml", "rabbitmq:\n  prefetch: 10\n")
+	file, err := NewFileLayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configuration := NewConfiguration(file).ValidateWith(func(view View) error {
+		if _, err := strconv.Atoi(view.GetString("RABBITMQ_PREFETCH", func() string { return "0" })); err != nil {
+			return fmt.Errorf("invalid prefetch: %v", err)
+		}
+		return nil
+	})
+	configuration.GetString("RABBITMQ_PREFETCH", func() string { return "0" })
+
+	notifications := 0
+	unsubscribe := configuration.Subscribe(func(changes []Change) {
+		notifications++
+	})
+	defer unsubscribe()
+
+	if err := os.WriteFile(path, []byte("rabbitmq:\n  prefetch: many\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := configuration.Reload(); err == nil {
+		t.Fatal("expected an invalid value not to be reloaded")
+	}
+
+	if value := configuration.GetString("RABBITMQ_PREFETCH", func() string { return "0" }); value != "10" || notifications != 0 {
+		t.Fatalf("expected the previous value to be kept without notification, but got: %s (%d notifications)", value, notifications)
+	}
+
+	if configuration.Modified() {
+		t.Fatal("expected the rejected file not to be reloaded again until it changes")
+	}
+
+	if err := os.WriteFile(path, []byte("rabbitmq:\n  prefetch: 30\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if changes, err := configuration.Reload(); err != nil || len(changes) != 1 || changes[0].Current != "30" || notifications != 1 {
+		t.Fatalf("expected the fixed value to be reloaded, but got: %v, %v (%d notifications)", changes, err, notifications)
+	}
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
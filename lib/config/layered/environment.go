@@ -0,0 +1,32 @@
+package layered
+
+import (
+	"github.com/joho/godotenv"
+	"os"
+)
+
+const (
+	SourceEnvironment = "environment"
+)
+
+func NewEnvironmentLayer() EnvironmentLayer {
+	_ = godotenv.Load()
+	return EnvironmentLayer{}
+}
+
+type EnvironmentLayer struct {
+}
+
+func (e EnvironmentLayer) Name() string {
+	return SourceEnvironment
+}
+
+func (e EnvironmentLayer) Lookup(name string) (string, bool) {
+	value := os.Getenv(name)
+
+	return value, value != ""
+}
+
+func (e EnvironmentLayer) Names() []string {
+	return nil
+}
@@ -0,0 +1,212 @@
+package layered
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SourceFile = "file"
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
+func NewFileLayer(path string) (*FileLayer, error) {
+	layer := &FileLayer{
+		path: path,
+		lock: &sync.RWMutex{},
+	}
+	if err := layer.Reload(); err != nil {
+		return nil, err
+	}
+
+	return layer, nil
+}
+
+// FileLayer keeps the last successfully parsed content of its file, so a
+// broken edit never wipes the values in use.
+type FileLayer struct {
+	path     string
+	lock     *sync.RWMutex
+	values   *ValuesLayer
+	previous *ValuesLayer
+	modTime  time.Time
+	size     int64
+}
+
+func (f *FileLayer) Name() string {
+	return SourceFile
+}
+
+func (f *FileLayer) Lookup(name string) (string, bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.values.Lookup(name)
+}
+
+func (f *FileLayer) Names() []string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.values.Names()
+}
+
+func (f *FileLayer) Modified() bool {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return false
+	}
+
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return !info.ModTime().Equal(f.modTime) || info.Size() != f.size
+}
+
+func (f *FileLayer) Reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("could not read configuration file %s: %v", f.path, err)
+	}
+
+	values, err := readFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.previous = f.values
+	f.values = NewValuesLayer(SourceFile, values)
+	f.modTime, f.size = info.ModTime(), info.Size()
+
+	return nil
+}
+
+// Rollback restores the content read before the last reload. The file is not
+// considered modified until it changes again.
+func (f *FileLayer) Rollback() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.previous != nil {
+		f.values = f.previous
+	}
+}
+
+func readFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read configuration file %s: %v", path, err)
+	}
+
+	document := make(map[string]interface{})
+	switch format := formatOf(path); format {
+	case FormatYAML:
+		err = yaml.Unmarshal(content, &document)
+	case FormatJSON:
+		err = json.Unmarshal(content, &document)
+	case FormatTOML:
+		err = toml.Unmarshal(content, &document)
+	default:
+		return nil, fmt.Errorf("unknown configuration file format: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse configuration file %s: %v", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten("", document, values); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %v", path, err)
+	}
+
+	return values, nil
+}
+
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	default:
+		return ""
+	}
+}
+
+func flatten(prefix string, value interface{}, values map[string]string) error {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range typed {
+			if err := flatten(join(prefix, key), nested, values); err != nil {
+				return err
+			}
+		}
+	case map[interface{}]interface{}:
+		for key, nested := range typed {
+			if err := flatten(join(prefix, fmt.Sprint(key)), nested, values); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		items := make([]string, 0, len(typed))
+		for _, item := range typed {
+			scalar, err := scalarOf(prefix, item)
+			if err != nil {
+				return err
+			}
+			items = append(items, scalar)
+		}
+		values[prefix] = strings.Join(items, ",")
+	default:
+		scalar, err := scalarOf(prefix, typed)
+		if err != nil {
+			return err
+		}
+		values[prefix] = scalar
+	}
+
+	return nil
+}
+
+func join(prefix string, key string) string {
+	if prefix == "" {
+		return NormalizeName(key)
+	}
+
+	return prefix + "_" + NormalizeName(key)
+}
+
+func scalarOf(name string, value interface{}) (string, error) {
+	switch typed := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return typed, nil
+	case bool:
+		return strconv.FormatBool(typed), nil
+	case int:
+		return strconv.Itoa(typed), nil
+	case int64:
+		return strconv.FormatInt(typed, 10), nil
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64), nil
+	case fmt.Stringer:
+		return typed.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported value for %s: %v", name, value)
+	}
+}
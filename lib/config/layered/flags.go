@@ -0,0 +1,72 @@
+package layered
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	SourceFlag = "flag"
+)
+
+func NewFlagLayer(args []string) (*ValuesLayer, error) {
+	values := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || strings.Trim(arg, "-") == "" {
+			return nil, fmt.Errorf("unexpected command-line argument: %s", arg)
+		}
+
+		parts := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)
+		name, value := parts[0], ""
+		if len(parts) == 2 {
+			value = parts[1]
+		} else {
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				value = args[i+1]
+				i++
+			} else {
+				value = "true"
+			}
+		}
+
+		values[NormalizeName(name)] = value
+	}
+
+	return NewValuesLayer(SourceFlag, values), nil
+}
+
+func NormalizeName(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+func NewValuesLayer(name string, values map[string]string) *ValuesLayer {
+	return &ValuesLayer{
+		name:   name,
+		values: values,
+	}
+}
+
+type ValuesLayer struct {
+	name   string
+	values map[string]string
+}
+
+func (v *ValuesLayer) Name() string {
+	return v.name
+}
+
+func (v *ValuesLayer) Lookup(name string) (string, bool) {
+	value, found := v.values[name]
+
+	return value, found
+}
+
+func (v *ValuesLayer) Names() []string {
+	names := make([]string, 0, len(v.values))
+	for name := range v.values {
+		names = append(names, name)
+	}
+
+	return names
+}
@@ -0,0 +1,34 @@
+package layered
+
+import (
+	"log"
+)
+
+// View resolves values straight from the layers without recording them, so a
+// reload can be validated before its values are applied.
+type View struct {
+	configuration *Configuration
+}
+
+func (v View) Close() error {
+	return nil
+}
+
+func (v View) GetString(name string, defaultProvider func() string) string {
+	if entry, found := v.configuration.lookup(name); found {
+		return entry.Value
+	}
+
+	return defaultProvider()
+}
+
+func (v View) GetStringOrCrash(name string) string {
+	return v.GetString(name, func() string {
+		log.Fatalf("missing mandatory property: %s", name)
+		return ""
+	})
+}
+
+func (v View) Failure(name string) error {
+	return v.configuration.failure(name)
+}
@@ -0,0 +1,95 @@
+package reload
+
+import (
+	"context"
+	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	WatchInterval        = "CONFIGURATION_WATCH_INTERVAL"
+	defaultWatchInterval = "5s"
+)
+
+func init() {
+	config.Register(config.Property{
+		Name:        WatchInterval,
+		Type:        config.TypeDuration,
+		Default:     defaultWatchInterval,
+		Description: "delay between two checks of the configuration file, 0 only reloads on SIGHUP",
+	})
+}
+
+func NewWatcher(configuration config.Configuration, logger logging.Logger) *Watcher {
+	interval, err := config.Duration(configuration, WatchInterval)
+	if err != nil {
+		logger.Fatalf("invalid configuration watch interval: %v", err)
+	}
+
+	return &Watcher{
+		configuration: configuration,
+		logger:        logger,
+		interval:      interval,
+	}
+}
+
+type Watcher struct {
+	configuration config.Configuration
+	logger        logging.Logger
+	interval      time.Duration
+}
+
+func (w *Watcher) Run(ctx context.Context) error {
+	reloadable, ok := w.configuration.(config.Reloadable)
+	if !ok {
+		w.logger.Infof("configuration cannot be reloaded")
+		return nil
+	}
+
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	var ticks <-chan time.Time
+	if w.interval > 0 {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	w.logger.Infof("watching configuration")
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hangups:
+			w.reload(reloadable, "hangup signal")
+		case <-ticks:
+			if reloadable.Modified() {
+				w.reload(reloadable, "file change")
+			}
+		}
+	}
+}
+
+func (w *Watcher) reload(reloadable config.Reloadable, reason string) {
+	w.logger.Infof("reloading configuration after %s", reason)
+	changes, err := reloadable.Reload()
+	if err != nil {
+		w.logger.Errorf("could not reload configuration, keeping the current one: %v", err)
+		return
+	}
+
+	for _, change := range changes {
+		w.logger.Infof("configuration property %s changed from (%s) to (%s), source: %s",
+			change.Name,
+			config.MaskSensitive(change.Name, change.Previous, change.Sensitive),
+			config.MaskSensitive(change.Name, change.Current, change.Sensitive),
+			change.Source,
+		)
+	}
+}
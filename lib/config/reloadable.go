@@ -0,0 +1,41 @@
+package config
+
+import (
+	"github.com/frederic-gendebien/pact-poc/lib/config/layered"
+)
+
+type Reloadable interface {
+	Modified() bool
+	Reload() ([]layered.Change, error)
+	Subscribe(subscriber func(changes []layered.Change)) func()
+}
+
+// OnChange notifies subscriber of the changes to the given names. It is a no-op
+// for configurations that cannot be reloaded.
+func OnChange(configuration Configuration, subscriber func(changes []layered.Change), names ...string) func() {
+	reloadable, ok := configuration.(Reloadable)
+	if !ok {
+		return func() {}
+	}
+
+	return reloadable.Subscribe(func(changes []layered.Change) {
+		if relevant := filterChanges(changes, names); len(relevant) > 0 {
+			subscriber(relevant)
+		}
+	})
+}
+
+func filterChanges(changes []layered.Change, names []string) []layered.Change {
+	if len(names) == 0 {
+		return changes
+	}
+
+	relevant := make([]layered.Change, 0, len(changes))
+	for _, change := range changes {
+		if contains(names, change.Name) {
+			relevant = append(relevant, change)
+		}
+	}
+
+	return relevant
+}
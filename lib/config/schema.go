@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	TypeString   Type = "string"
+	TypeInt      Type = "int"
+	TypeFloat    Type = "float"
+	TypeBool     Type = "bool"
+	TypeDuration Type = "duration"
+	TypeURL      Type = "url"
+	TypeList     Type = "list"
+)
+
+var (
+	schema = NewSchema()
+)
+
+type Condition struct {
+	Name  string
+	Value string
+}
+
+// Property describes a configuration key. A property with a Condition is
+// only validated when the condition key resolves to the condition value.
+type Property struct {
+	Name        string
+	Type        Type
+	Default     string
+	Required    bool
+	Allowed     []string
+	Description string
+	When        *Condition
+}
+
+func (p Property) applies(configuration Configuration) bool {
+	if p.When == nil {
+		return true
+	}
+
+	return String(configuration, p.When.Name) == p.When.Value
+}
+
+func (p Property) parse(value string) error {
+	var err error
+	switch p.Type {
+	case TypeInt:
+		_, err = strconv.Atoi(value)
+	case TypeFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case TypeBool:
+		_, err = strconv.ParseBool(value)
+	case TypeDuration:
+		_, err = time.ParseDuration(value)
+	case TypeURL:
+		_, err = parseURL(value)
+	}
+	if err != nil {
+		return NewInvalidPropertyError(p.Name, p.Type, err)
+	}
+
+	if len(p.Allowed) > 0 && !contains(p.Allowed, value) {
+		return NewUnsupportedPropertyError(p.Name, p.Allowed)
+	}
+
+	return nil
+}
+
+func When(name string, value string, properties ...Property) []Property {
+	conditional := make([]Property, len(properties))
+	for i, property := range properties {
+		property.When = &Condition{
+			Name:  name,
+			Value: value,
+		}
+		conditional[i] = property
+	}
+
+	return conditional
+}
+
+func NewSchema() *Schema {
+	return &Schema{
+		lock:       &sync.RWMutex{},
+		properties: make(map[string]Property),
+	}
+}
+
+type Schema struct {
+	lock       *sync.RWMutex
+	properties map[string]Property
+}
+
+func (s *Schema) Register(properties ...Property) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, property := range properties {
+		if property.Type == "" {
+			property.Type = TypeString
+		}
+
+		if registered, found := s.properties[property.Name]; found && !sameProperty(registered, property) {
+			panic(fmt.Sprintf("property %s is already registered with a different definition", property.Name))
+		}
+
+		s.properties[property.Name] = property
+	}
+}
+
+func (s *Schema) Lookup(name string) (Property, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	property, found := s.properties[name]
+
+	return property, found
+}
+
+func (s *Schema) Properties() []Property {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	properties := make([]Property, 0, len(s.properties))
+	for _, property := range s.properties {
+		properties = append(properties, property)
+	}
+
+	sort.Slice(properties, func(i, j int) bool {
+		return properties[i].Name < properties[j].Name
+	})
+
+	return properties
+}
+
+func (s *Schema) Validate(configuration Configuration) error {
+	var problems []PropertyError
+	for _, property := range s.Properties() {
+		if !property.applies(configuration) {
+			continue
+		}
+
+		value := resolve(configuration, []string{property.Name}, property.Default)
+		if err := failureOf(configuration, property.Name); err != nil {
+			problems = append(problems, NewUnresolvedPropertyError(property.Name, err))
+			continue
+		}
+
+		if value == "" {
+			if property.Required {
+				problems = append(problems, NewMissingPropertyError(property.Name))
+			}
+			continue
+		}
+
+		if err := property.parse(value); err != nil {
+			problems = append(problems, err.(PropertyError))
+		}
+	}
+
+	if len(problems) > 0 {
+		return ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+func Register(properties ...Property) {
+	schema.Register(properties...)
+}
+
+func Properties() []Property {
+	return schema.Properties()
+}
+
+func Validate(configuration Configuration) error {
+	return schema.Validate(configuration)
+}
+
+func failureOf(configuration Configuration, name string) error {
+	if resolver, ok := configuration.(interface{ Failure(name string) error }); ok {
+		return resolver.Failure(name)
+	}
+
+	return nil
+}
+
+func sameProperty(left Property, right Property) bool {
+	return left.Type == right.Type &&
+		left.Default == right.Default &&
+		left.Required == right.Required &&
+		strings.Join(left.Allowed, ",") == strings.Join(right.Allowed, ",") &&
+		sameCondition(left.When, right.When)
+}
+
+func sameCondition(left *Condition, right *Condition) bool {
+	if left == nil || right == nil {
+		return left == right
+	}
+
+	return *left == *right
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"github.com/frederic-gendebien/pact-poc/lib/config/layered"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSchema_Validate(t *testing.T) {
+	schema := NewSchema()
+	schema.Register(
+		Property{Name: "SCHEMA_MODE", Required: true, Allowed: []string{"memory", "remote"}},
+		Property{Name: "SCHEMA_TIMEOUT", Type: TypeDuration, Default: "1s"},
+		Property{Name: "SCHEMA_WORKERS", Type: TypeInt},
+	)
+	schema.Register(When("SCHEMA_MODE", "remote",
+		Property{Name: "SCHEMA_URL", Type: TypeURL, Required: true},
+	)...)
+
+	valid := configurationWith(map[string]string{"SCHEMA_MODE": "memory"})
+	if err := schema.Validate(valid); err != nil {
+		t.Fatalf("expected a valid configuration, but got: %v", err)
+	}
+
+	invalid := configurationWith(map[string]string{
+		"SCHEMA_MODE":    "remote",
+		"SCHEMA_TIMEOUT": "soon",
+		"SCHEMA_WORKERS": "many",
+	})
+	err := schema.Validate(invalid)
+	if !errors.Is(err, ValidationError{}) {
+		t.Fatalf("expected a validation error, but got: %v", err)
+	}
+
+	var validationError ValidationError
+	errors.As(err, &validationError)
+	names := make([]string, len(validationError.Problems))
+	for i, problem := range validationError.Problems {
+		names[i] = problem.Name
+	}
+	if expected := []string{"SCHEMA_TIMEOUT", "SCHEMA_URL", "SCHEMA_WORKERS"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected problems on %v, but got: %v", expected, err)
+	}
+}
+
+func TestSchema_RegisterConflict(t *testing.T) {
+	schema := NewSchema()
+	schema.Register(Property{Name: "SCHEMA_PORT", Type: TypeInt, Default: "8080"})
+	schema.Register(Property{Name: "SCHEMA_PORT", Type: TypeInt, Default: "8080"})
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a conflicting registration to panic")
+		}
+	}()
+	schema.Register(Property{Name: "SCHEMA_PORT", Type: TypeInt, Default: "9090"})
+}
+
+func TestGetters(t *testing.T) {
+	Register(
+		Property{Name: "GETTERS_PREFETCH", Type: TypeInt, Default: "10"},
+		Property{Name: "GETTERS_DELAY", Type: TypeDuration, Default: "1m"},
+		Property{Name: "GETTERS_URL", Type: TypeURL, Required: true},
+	)
+	configuration := configurationWith(map[string]string{
+		"GETTERS_LISTENER_PREFETCH": "20",
+		"GETTERS_ENABLED":           "true",
+		"GETTERS_TAGS":              "a, b,,c",
+	})
+
+	if prefetch, err := Int(configuration, "GETTERS_LISTENER_PREFETCH", "GETTERS_PREFETCH"); err != nil || prefetch != 20 {
+		t.Fatalf("expected the listener prefetch, but got: %d %v", prefetch, err)
+	}
+
+	if prefetch, err := Int(configuration, "GETTERS_OTHER_PREFETCH", "GETTERS_PREFETCH"); err != nil || prefetch != 10 {
+		t.Fatalf("expected the default prefetch, but got: %d %v", prefetch, err)
+	}
+
+	if delay, err := Duration(configuration, "GETTERS_DELAY"); err != nil || delay != time.Minute {
+		t.Fatalf("expected the default delay, but got: %v %v", delay, err)
+	}
+
+	if enabled, err := Bool(configuration, "GETTERS_ENABLED"); err != nil || !enabled {
+		t.Fatalf("expected enabled, but got: %v %v", enabled, err)
+	}
+
+	if tags, err := List(configuration, "GETTERS_TAGS"); err != nil || !reflect.DeepEqual(tags, []string{"a", "b", "c"}) {
+		t.Fatalf("expected tags, but got: %v %v", tags, err)
+	}
+
+	if _, err := URL(configuration, "GETTERS_URL"); !errors.Is(err, PropertyError{}) {
+		t.Fatalf("expected a missing property error, but got: %v", err)
+	}
+
+	if _, err := Int(configuration, "GETTERS_TAGS"); !errors.Is(err, PropertyError{}) {
+		t.Fatalf("expected an invalid property error, but got: %v", err)
+	}
+}
+
+func configurationWith(values map[string]string) Configuration {
+	return layered.NewConfiguration(layered.NewValuesLayer("test", values))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/config/secret"
+	"log"
+	"os"
+)
+
+// seal writes to stdout the secrets file sealing the JSON object read from
+// stdin, with the key from SECRETS_KEY. -keygen prints a new key instead.
+func main() {
+	keygen := flag.Bool("keygen", false, "print a new base64 key")
+	flag.Parse()
+
+	if *keygen {
+		key, err := secret.NewKey()
+		if err != nil {
+			log.Fatalf("could not generate key: %v", err)
+		}
+		fmt.Println(key)
+		return
+	}
+
+	key, err := secret.DecodeKey(os.Getenv(secret.StoreKey))
+	if err != nil {
+		log.Fatalf("invalid key: %v", err)
+	}
+
+	secrets := make(map[string]string)
+	if err := json.NewDecoder(os.Stdin).Decode(&secrets); err != nil {
+		log.Fatalf("could not read secrets from stdin: %v", err)
+	}
+
+	sealed, err := secret.Seal(key, secrets)
+	if err != nil {
+		log.Fatalf("could not seal secrets: %v", err)
+	}
+
+	if _, err := os.Stdout.Write(sealed); err != nil {
+		log.Fatalf("could not write sealed secrets: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package secret
+
+import (
+	"github.com/frederic-gendebien/pact-poc/lib/config/layered"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+func NewLayer(layer layered.Layer, providers ...Provider) *Layer {
+	byScheme := make(map[string]Provider)
+	for _, provider := range providers {
+		byScheme[provider.Scheme()] = provider
+	}
+
+	return &Layer{
+		layer:     layer,
+		providers: byScheme,
+		lock:      &sync.Mutex{},
+		resolved:  make(map[string]resolution),
+	}
+}
+
+// Layer resolves the secret references held by the layer it wraps. Resolved
+// values are cached until the next reload, which resolves them again to
+// pick up rotated secrets.
+type Layer struct {
+	layer     layered.Layer
+	providers map[string]Provider
+	lock      *sync.Mutex
+	resolved  map[string]resolution
+	previous  map[string]resolution
+}
+
+type resolution struct {
+	reference string
+	value     string
+	err       error
+}
+
+func (l *Layer) Name() string {
+	return l.layer.Name()
+}
+
+func (l *Layer) Names() []string {
+	return l.layer.Names()
+}
+
+func (l *Layer) Lookup(name string) (string, bool) {
+	raw, found := l.layer.Lookup(name)
+	if !found {
+		return "", false
+	}
+
+	provider, reference, isReference := l.referenceOf(raw)
+	if !isReference {
+		return raw, true
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	cached, cachedFound := l.resolved[name]
+	if !cachedFound || cached.reference != raw {
+		value, err := provider.Resolve(reference)
+		cached = resolution{
+			reference: raw,
+			value:     value,
+			err:       err,
+		}
+		l.resolved[name] = cached
+	}
+
+	return cached.value, true
+}
+
+func (l *Layer) Sensitive(name string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	_, found := l.resolved[name]
+
+	return found
+}
+
+func (l *Layer) Failure(name string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.resolved[name].err
+}
+
+func (l *Layer) Modified() bool {
+	if reloader, ok := l.layer.(layered.Reloader); ok && reloader.Modified() {
+		return true
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for _, cached := range l.resolved {
+		provider, reference, _ := l.referenceOf(cached.reference)
+		if value, err := provider.Resolve(reference); value != cached.value || (err == nil) != (cached.err == nil) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *Layer) Reload() error {
+	if reloader, ok := l.layer.(layered.Reloader); ok {
+		if err := reloader.Reload(); err != nil {
+			return err
+		}
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.previous = l.resolved
+	l.resolved = make(map[string]resolution)
+
+	return nil
+}
+
+func (l *Layer) Rollback() {
+	if reloader, ok := l.layer.(layered.Reloader); ok {
+		reloader.Rollback()
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.previous != nil {
+		l.resolved = l.previous
+	}
+}
+
+func (l *Layer) referenceOf(raw string) (Provider, *url.URL, bool) {
+	scheme := strings.SplitN(raw, "://", 2)[0]
+	provider, found := l.providers[scheme]
+	if !found || !strings.Contains(raw, "://") {
+		return nil, nil, false
+	}
+
+	reference, err := url.Parse(raw)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	return provider, reference, true
+}
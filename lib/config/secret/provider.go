@@ -0,0 +1,51 @@
+package secret
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	SchemeFile  = "file"
+	SchemeStore = "secret"
+)
+
+type Provider interface {
+	Scheme() string
+	Resolve(reference *url.URL) (string, error)
+}
+
+func DefaultProviders() []Provider {
+	return []Provider{
+		NewFileProvider(),
+		NewStoreProvider(os.Getenv(StorePath), os.Getenv(StoreKey)),
+	}
+}
+
+func NewFileProvider() FileProvider {
+	return FileProvider{}
+}
+
+// FileProvider reads file:///run/secrets/name references, which is where
+// Docker Swarm mounts the secrets of a service.
+type FileProvider struct {
+}
+
+func (f FileProvider) Scheme() string {
+	return SchemeFile
+}
+
+func (f FileProvider) Resolve(reference *url.URL) (string, error) {
+	if reference.Path == "" {
+		return "", fmt.Errorf("missing path in secret reference")
+	}
+
+	content, err := os.ReadFile(reference.Path)
+	if err != nil {
+		return "", fmt.Errorf("could not read secret file %s: %v", reference.Path, err)
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
@@ -0,0 +1,152 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	StorePath = "SECRETS_FILE"
+	StoreKey  = "SECRETS_KEY"
+	KeySize   = 32
+)
+
+var (
+	ErrUndecipherable = errors.New("secrets file cannot be decrypted with this key")
+)
+
+func NewStoreProvider(path string, key string) StoreProvider {
+	return StoreProvider{
+		path: path,
+		key:  key,
+	}
+}
+
+// StoreProvider reads secret://name references from a local file sealed with
+// AES-256-GCM, whose base64 key is only given through the environment.
+type StoreProvider struct {
+	path string
+	key  string
+}
+
+func (s StoreProvider) Scheme() string {
+	return SchemeStore
+}
+
+func (s StoreProvider) Resolve(reference *url.URL) (string, error) {
+	name := reference.Host + strings.TrimPrefix(reference.Path, "/")
+	if name == "" {
+		return "", fmt.Errorf("missing name in secret reference")
+	}
+
+	if s.path == "" || s.key == "" {
+		return "", fmt.Errorf("%s and %s are needed to resolve secret %s", StorePath, StoreKey, name)
+	}
+
+	key, err := DecodeKey(s.key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", fmt.Errorf("could not read secrets file %s: %v", s.path, err)
+	}
+
+	secrets, err := Open(key, sealed)
+	if err != nil {
+		return "", err
+	}
+
+	value, found := secrets[name]
+	if !found {
+		return "", fmt.Errorf("unknown secret %s in %s", name, s.path)
+	}
+
+	return value, nil
+}
+
+func NewKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+func DecodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("%s must be %d base64 encoded bytes", StoreKey, KeySize)
+	}
+
+	return key, nil
+}
+
+func Seal(key []byte, secrets map[string]string) ([]byte, error) {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+
+	return []byte(base64.StdEncoding.EncodeToString(sealed) + "\n"), nil
+}
+
+func Open(key []byte, content []byte) (map[string]string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, ErrUndecipherable
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrUndecipherable
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrUndecipherable
+	}
+
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, ErrUndecipherable
+	}
+
+	return secrets, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
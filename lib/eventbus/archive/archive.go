@@ -0,0 +1,89 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"io"
+)
+
+const (
+	Mode         = "EVENTBUS_ARCHIVE_MODE"
+	ModeDisabled = "disabled"
+	ModeFile     = "file"
+	Path         = "EVENTBUS_ARCHIVE_PATH"
+)
+
+var (
+	ErrDisabled = errors.New("event archive is disabled")
+)
+
+func init() {
+	config.Register(config.Property{
+		Name:        Mode,
+		Default:     ModeDisabled,
+		Allowed:     []string{ModeDisabled, ModeFile},
+		Description: "event archive implementation",
+	})
+	config.Register(config.When(Mode, ModeFile, config.Property{
+		Name:        Path,
+		Required:    true,
+		Description: "file the consumed events are archived to",
+	})...)
+}
+
+type Archive interface {
+	io.Closer
+	Append(ctx context.Context, envelope domain.Envelope) error
+	Count(ctx context.Context, domainName string) (int, error)
+	Replay(ctx context.Context, domainName string, from int, handle func(envelope domain.Envelope) error) (int, error)
+}
+
+func NewArchive(configuration config.Configuration, logger logging.Logger) Archive {
+	mode := config.String(configuration, Mode)
+	switch mode {
+	case ModeDisabled:
+		return disabledArchive{}
+	case ModeFile:
+		path, err := config.MandatoryString(configuration, Path)
+		if err != nil {
+			logger.Fatalf("invalid event archive configuration: %v", err)
+		}
+
+		archive, err := NewFileArchive(path, logger)
+		if err != nil {
+			logger.Fatalf("could not open event archive: %v", err)
+		}
+		return archive
+	default:
+		logger.Fatalf("unknown event archive mode: %s", mode)
+		return nil
+	}
+}
+
+func IsEnabled(archive Archive) bool {
+	_, disabled := archive.(disabledArchive)
+
+	return !disabled
+}
+
+type disabledArchive struct {
+}
+
+func (d disabledArchive) Close() error {
+	return nil
+}
+
+func (d disabledArchive) Append(ctx context.Context, envelope domain.Envelope) error {
+	return nil
+}
+
+func (d disabledArchive) Count(ctx context.Context, domainName string) (int, error) {
+	return 0, ErrDisabled
+}
+
+func (d disabledArchive) Replay(ctx context.Context, domainName string, from int, handle func(envelope domain.Envelope) error) (int, error) {
+	return from, ErrDisabled
+}
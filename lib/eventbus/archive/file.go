@@ -0,0 +1,105 @@
+package archive
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"io"
+	"os"
+	"sync"
+)
+
+func NewFileArchive(path string, logger logging.Logger) (*FileArchive, error) {
+	logger.Infof("starting file event archive: %s", path)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileArchive{
+		path:   path,
+		logger: logger,
+		lock:   &sync.Mutex{},
+		file:   file,
+	}, nil
+}
+
+type FileArchive struct {
+	path   string
+	logger logging.Logger
+	lock   *sync.Mutex
+	file   *os.File
+}
+
+func (f *FileArchive) Close() error {
+	f.logger.Infof("closing file event archive")
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.file.Close()
+}
+
+func (f *FileArchive) Append(ctx context.Context, envelope domain.Envelope) error {
+	line, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("could not archive event %s: %v", envelope.Metadata.EventId, err)
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, err := f.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("could not archive event %s: %v", envelope.Metadata.EventId, err)
+	}
+
+	return f.file.Sync()
+}
+
+func (f *FileArchive) Count(ctx context.Context, domainName string) (int, error) {
+	return f.Replay(ctx, domainName, 0, func(envelope domain.Envelope) error {
+		return nil
+	})
+}
+
+func (f *FileArchive) Replay(ctx context.Context, domainName string, from int, handle func(envelope domain.Envelope) error) (int, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return from, err
+	}
+	defer file.Close()
+
+	position := 0
+	reader := bufio.NewReader(file)
+	for {
+		if err := ctx.Err(); err != nil {
+			return position, err
+		}
+
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return position, nil
+		}
+		if err != nil {
+			return position, err
+		}
+
+		envelope := domain.Envelope{}
+		if err := json.Unmarshal(line, &envelope); err != nil {
+			return position, fmt.Errorf("corrupted event archive at record %d: %v", position, err)
+		}
+
+		if envelope.Metadata.Domain != domainName {
+			continue
+		}
+
+		if position >= from {
+			if err := handle(envelope); err != nil {
+				return position, err
+			}
+		}
+		position++
+	}
+}
@@ -0,0 +1,58 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileArchive_Replay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	archive, err := NewFileArchive(path, logging.NewDiscardLogger())
+	if err != nil {
+		t.Fatalf("could not open archive: %v", err)
+	}
+	defer archive.Close()
+
+	for i := 0; i < 4; i++ {
+		domainName := "user"
+		if i == 1 {
+			domainName = "order"
+		}
+
+		event := domain.NewRawEvent(domainName, "happened", fmt.Sprintf("entity%d", i), []byte(fmt.Sprintf(`{"index":%d}`, i)))
+		if err := archive.Append(ctx, domain.NewEnvelope(ctx, event)); err != nil {
+			t.Fatalf("could not append event: %v", err)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("could not open archive file: %v", err)
+	}
+	_, _ = file.WriteString(`{"metadata":{"domain":"user"`)
+	_ = file.Close()
+
+	if count, err := archive.Count(ctx, "user"); err != nil || count != 3 {
+		t.Fatalf("expected 3 user events, but got: %d, %v", count, err)
+	}
+
+	entityIds := make([]string, 0, 2)
+	next, err := archive.Replay(ctx, "user", 1, func(envelope domain.Envelope) error {
+		entityIds = append(entityIds, envelope.Metadata.EntityId)
+		return nil
+	})
+	if err != nil || next != 3 {
+		t.Fatalf("expected replay to stop at position 3, but got: %d, %v", next, err)
+	}
+
+	if expected := []string{"entity2", "entity3"}; !reflect.DeepEqual(entityIds, expected) {
+		t.Fatalf("expected: %v, but got %v", expected, entityIds)
+	}
+}
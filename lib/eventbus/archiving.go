@@ -0,0 +1,86 @@
+package eventbus
+
+import (
+	"context"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/archive"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	archivedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "eventbus_archived_events_total",
+		Help: "Number of consumed events appended to the event archive by outcome.",
+	}, []string{"listener", "domain", "name", "outcome"})
+)
+
+// WithArchive records the events consumed through the event bus in the
+// archive, so the consumer can later replay what it received. The archive is
+// owned by the caller, who closes it.
+func WithArchive(eventBus EventBus, eventArchive archive.Archive, logger logging.Logger) EventBus {
+	if !archive.IsEnabled(eventArchive) {
+		return eventBus
+	}
+
+	return NewArchivingEventBus(eventBus, eventArchive, logger)
+}
+
+func NewArchivingEventBus(eventBus EventBus, eventArchive archive.Archive, logger logging.Logger) *ArchivingEventBus {
+	return &ArchivingEventBus{
+		EventBus: eventBus,
+		archive:  eventArchive,
+		logger:   logger,
+	}
+}
+
+type ArchivingEventBus struct {
+	EventBus
+	archive archive.Archive
+	logger  logging.Logger
+}
+
+func (a *ArchivingEventBus) Listen(ctx context.Context, listenerName string, eventHandlers ...domain.EventHandler) error {
+	return a.EventBus.Listen(ctx, listenerName, a.archivingHandlers(listenerName, eventHandlers)...)
+}
+
+func (a *ArchivingEventBus) ListenWithOptions(ctx context.Context, listenerName string, options domain.ListenerOptions, eventHandlers ...domain.EventHandler) error {
+	return a.EventBus.ListenWithOptions(ctx, listenerName, options, a.archivingHandlers(listenerName, eventHandlers)...)
+}
+
+func (a *ArchivingEventBus) archivingHandlers(listenerName string, eventHandlers []domain.EventHandler) []domain.EventHandler {
+	archiving := make([]domain.EventHandler, 0, len(eventHandlers))
+	for _, eventHandler := range eventHandlers {
+		archiving = append(archiving, archivingHandler{
+			EventHandler: eventHandler,
+			listenerName: listenerName,
+			archive:      a.archive,
+			logger:       a.logger.With(logging.Listener, listenerName),
+		})
+	}
+
+	return archiving
+}
+
+type archivingHandler struct {
+	domain.EventHandler
+	listenerName string
+	archive      archive.Archive
+	logger       logging.Logger
+}
+
+// ProcessEvent archives the event before handling it. An archive failure is
+// logged and counted but does not fail the delivery, which would otherwise be
+// redelivered and processed twice.
+func (a archivingHandler) ProcessEvent(envelope domain.Envelope) error {
+	definition := a.GetEventDefinition()
+	err := a.archive.Append(context.Background(), envelope)
+	if err != nil {
+		a.logger.Errorf("could not archive event %s: %v", envelope.Metadata.EventId, err)
+	}
+	archivedEvents.WithLabelValues(a.listenerName, definition.GetDomain(), definition.GetName(), metrics.Outcome(err)).Inc()
+
+	return a.EventHandler.ProcessEvent(envelope)
+}
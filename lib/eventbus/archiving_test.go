@@ -0,0 +1,113 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/inmemory"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"testing"
+)
+
+type happened struct {
+	Id string `json:"id"`
+}
+
+func (h happened) GetDefinition() domain.EventDefinition {
+	return happenedDefinition{}
+}
+
+func (h happened) GetEntityId() string {
+	return h.Id
+}
+
+func (h happened) GetPayload() interface{} {
+	return h
+}
+
+type happenedDefinition struct {
+}
+
+func (h happenedDefinition) GetDomain() string {
+	return "test"
+}
+
+func (h happenedDefinition) GetName() string {
+	return "happened"
+}
+
+func (h happenedDefinition) GetType() interface{} {
+	return &happened{}
+}
+
+type recordingHandler struct {
+	processed *[]string
+}
+
+func (r recordingHandler) GetEventDefinition() domain.EventDefinition {
+	return happenedDefinition{}
+}
+
+func (r recordingHandler) ProcessEvent(envelope domain.Envelope) error {
+	*r.processed = append(*r.processed, envelope.GetEntityId())
+	return nil
+}
+
+func (r recordingHandler) HandleError(envelope domain.Envelope, err error) {
+}
+
+type recordingArchive struct {
+	failure  error
+	appended []string
+}
+
+func (r *recordingArchive) Close() error {
+	return nil
+}
+
+func (r *recordingArchive) Append(ctx context.Context, envelope domain.Envelope) error {
+	if r.failure != nil {
+		return r.failure
+	}
+
+	r.appended = append(r.appended, envelope.GetEntityId())
+	return nil
+}
+
+func (r *recordingArchive) Count(ctx context.Context, domainName string) (int, error) {
+	return len(r.appended), nil
+}
+
+func (r *recordingArchive) Replay(ctx context.Context, domainName string, from int, handle func(envelope domain.Envelope) error) (int, error) {
+	return from, nil
+}
+
+func TestArchivingEventBus_ArchivesConsumedEvents(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewDiscardLogger()
+	eventArchive := &recordingArchive{}
+	publisher := inmemory.NewEventBus(logger)
+	consumer := WithArchive(publisher, eventArchive, logger)
+
+	processed := make([]string, 0)
+	if err := consumer.Listen(ctx, "listener", recordingHandler{processed: &processed}); err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+
+	if err := consumer.Publish(ctx, happened{Id: "published"}); err != nil {
+		t.Fatalf("could not publish: %v", err)
+	}
+
+	if len(eventArchive.appended) != 1 || eventArchive.appended[0] != "published" {
+		t.Fatalf("expected the consumed event to be archived once, but got: %v", eventArchive.appended)
+	}
+
+	eventArchive.failure = errors.New("disk full")
+	if err := publisher.Publish(ctx, happened{Id: "unarchived"}); err != nil {
+		t.Fatalf("could not publish: %v", err)
+	}
+
+	if len(processed) != 2 || processed[1] != "unarchived" {
+		t.Fatalf("expected the event to be processed despite the archive failure, but got: %v", processed)
+	}
+}
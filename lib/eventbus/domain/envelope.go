@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"time"
+)
+
+const (
+	DefaultSchemaVersion = 1
+)
+
+type VersionedEvent interface {
+	GetSchemaVersion() int
+}
+
+type Metadata struct {
+	EventId       string            `json:"event_id"`
+	Domain        string            `json:"domain"`
+	Name          string            `json:"name"`
+	EntityId      string            `json:"entity_id"`
+	Sequence      int               `json:"sequence,omitempty"`
+	OccurredAt    time.Time         `json:"occurred_at"`
+	CorrelationId string            `json:"correlation_id"`
+	CausationId   string            `json:"causation_id,omitempty"`
+	SchemaVersion int               `json:"schema_version"`
+	TraceContext  map[string]string `json:"trace_context,omitempty"`
+}
+
+func NewEnvelope(ctx context.Context, event Event) Envelope {
+	if envelope, ok := event.(Envelope); ok {
+		return envelope
+	}
+
+	eventId := NewEventId()
+	correlationId, causationId := causalityFrom(ctx)
+	if correlationId == "" {
+		correlationId = eventId
+	}
+
+	return Envelope{
+		Metadata: Metadata{
+			EventId:       eventId,
+			Domain:        event.GetDefinition().GetDomain(),
+			Name:          event.GetDefinition().GetName(),
+			EntityId:      event.GetEntityId(),
+			OccurredAt:    time.Now().UTC(),
+			CorrelationId: correlationId,
+			CausationId:   causationId,
+			SchemaVersion: schemaVersionOf(event),
+		},
+		Event: event,
+	}
+}
+
+type Envelope struct {
+	Metadata Metadata
+	Event    Event
+}
+
+func (e Envelope) GetDefinition() EventDefinition {
+	return e.Event.GetDefinition()
+}
+
+func (e Envelope) GetEntityId() string {
+	return e.Event.GetEntityId()
+}
+
+func (e Envelope) GetPayload() interface{} {
+	return e.Event.GetPayload()
+}
+
+func (e Envelope) WithEvent(event Event) Envelope {
+	e.Event = event
+	return e
+}
+
+// WithTraceContext returns the envelope carrying the trace context, unless it
+// already carries the one of the operation that created it.
+func (e Envelope) WithTraceContext(traceContext map[string]string) Envelope {
+	if e.Metadata.TraceContext == nil {
+		e.Metadata.TraceContext = traceContext
+	}
+
+	return e
+}
+
+func NewEventId() string {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	bytes[6] = (bytes[6] & 0x0f) | 0x40
+	bytes[8] = (bytes[8] & 0x3f) | 0x80
+	id := hex.EncodeToString(bytes)
+
+	return fmt.Sprintf("%s-%s-%s-%s-%s", id[0:8], id[8:12], id[12:16], id[16:20], id[20:32])
+}
+
+func schemaVersionOf(event Event) int {
+	if versioned, ok := event.(VersionedEvent); ok {
+		return versioned.GetSchemaVersion()
+	}
+
+	return DefaultSchemaVersion
+}
+
+type causalityKey struct{}
+
+type causality struct {
+	correlationId string
+	causationId   string
+}
+
+func WithCorrelationId(ctx context.Context, correlationId string) context.Context {
+	return context.WithValue(ctx, causalityKey{}, causality{
+		correlationId: correlationId,
+	})
+}
+
+func CausedBy(ctx context.Context, envelope Envelope) context.Context {
+	ctx = logging.WithField(ctx, logging.EventId, envelope.Metadata.EventId)
+	ctx = logging.WithField(ctx, logging.CorrelationId, envelope.Metadata.CorrelationId)
+
+	return context.WithValue(ctx, causalityKey{}, causality{
+		correlationId: envelope.Metadata.CorrelationId,
+		causationId:   envelope.Metadata.EventId,
+	})
+}
+
+func causalityFrom(ctx context.Context) (string, string) {
+	if ctx == nil {
+		return "", ""
+	}
+
+	if value, ok := ctx.Value(causalityKey{}).(causality); ok {
+		return value.correlationId, value.causationId
+	}
+
+	return "", ""
+}
@@ -14,6 +14,28 @@ type Event interface {
 
 type EventHandler interface {
 	GetEventDefinition() EventDefinition
-	ProcessEvent(interface{}) error
-	HandleError(interface{}, error)
+	ProcessEvent(envelope Envelope) error
+	HandleError(envelope Envelope, err error)
+}
+
+type ListenerOptions struct {
+	Concurrency     int  `json:"concurrency"`
+	Prefetch        int  `json:"prefetch"`
+	OrderedByEntity bool `json:"ordered_by_entity"`
+}
+
+func (o ListenerOptions) Workers() int {
+	if o.Concurrency < 1 {
+		return 1
+	}
+
+	return o.Concurrency
+}
+
+func (o ListenerOptions) PrefetchCount() int {
+	if o.Prefetch < 1 {
+		return o.Workers()
+	}
+
+	return o.Prefetch
 }
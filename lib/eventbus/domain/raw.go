@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func NewRawEvent(domain string, name string, entityId string, payload []byte) RawEvent {
+	return RawEvent{
+		Definition: RawDefinition{
+			Domain: domain,
+			Name:   name,
+		},
+		EntityId: entityId,
+		Payload:  payload,
+	}
+}
+
+type RawEvent struct {
+	Definition RawDefinition
+	EntityId   string
+	Payload    json.RawMessage
+}
+
+func (r RawEvent) GetDefinition() EventDefinition {
+	return r.Definition
+}
+
+func (r RawEvent) GetEntityId() string {
+	return r.EntityId
+}
+
+func (r RawEvent) GetPayload() interface{} {
+	return r.Payload
+}
+
+type RawDefinition struct {
+	Domain string
+	Name   string
+}
+
+func (r RawDefinition) GetDomain() string {
+	return r.Domain
+}
+
+func (r RawDefinition) GetName() string {
+	return r.Name
+}
+
+func (r RawDefinition) GetType() interface{} {
+	return &json.RawMessage{}
+}
+
+func DecodeEnvelope(envelope Envelope, definition EventDefinition) (Envelope, error) {
+	raw, ok := envelope.Event.(RawEvent)
+	if !ok {
+		return envelope, nil
+	}
+
+	eventType := definition.GetType()
+	if err := json.Unmarshal(raw.Payload, eventType); err != nil {
+		return envelope, err
+	}
+
+	event, ok := eventType.(Event)
+	if !ok {
+		return envelope, fmt.Errorf("type of event %s/%s is not an event", definition.GetDomain(), definition.GetName())
+	}
+
+	return envelope.WithEvent(event), nil
+}
+
+type jsonEnvelope struct {
+	Metadata Metadata        `json:"metadata"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+func (e Envelope) MarshalJSON() ([]byte, error) {
+	payload, err := json.Marshal(e.GetPayload())
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jsonEnvelope{
+		Metadata: e.Metadata,
+		Payload:  payload,
+	})
+}
+
+func (e *Envelope) UnmarshalJSON(bytes []byte) error {
+	decoded := jsonEnvelope{}
+	if err := json.Unmarshal(bytes, &decoded); err != nil {
+		return err
+	}
+
+	e.Metadata = decoded.Metadata
+	e.Event = NewRawEvent(decoded.Metadata.Domain, decoded.Metadata.Name, decoded.Metadata.EntityId, decoded.Payload)
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package eventbus
+
+import (
+	"context"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/tracing"
+)
+
+// NewEnvelope wraps the event with the trace context of ctx, which the
+// envelope keeps when it is published later on, e.g. from an outbox.
+func NewEnvelope(ctx context.Context, event domain.Event) domain.Envelope {
+	return domain.NewEnvelope(ctx, event).WithTraceContext(tracing.Inject(ctx))
+}
+
+// CausedBy returns the context of the operations caused by the envelope,
+// continuing the trace it carries.
+func CausedBy(ctx context.Context, envelope domain.Envelope) context.Context {
+	return tracing.Extract(domain.CausedBy(ctx, envelope), envelope.Metadata.TraceContext)
+}
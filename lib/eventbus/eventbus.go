@@ -6,8 +6,9 @@ import (
 	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
 	"github.com/frederic-gendebien/pact-poc/lib/eventbus/inmemory"
 	"github.com/frederic-gendebien/pact-poc/lib/eventbus/rabbitmq"
+	"github.com/frederic-gendebien/pact-poc/lib/health"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
 	"io"
-	"log"
 )
 
 const (
@@ -16,21 +17,42 @@ const (
 	modeRabbitMQ = "rabbitmq"
 )
 
-func NewEventBus(configuration config.Configuration) EventBus {
-	mode := configuration.GetStringOrCrash(mode)
+func init() {
+	config.Register(config.Property{
+		Name:        mode,
+		Required:    true,
+		Allowed:     []string{modeInMemory, modeRabbitMQ},
+		Description: "event bus implementation",
+	})
+	config.Register(config.When(mode, modeRabbitMQ, rabbitmq.Properties()...)...)
+}
+
+func NewEventBus(configuration config.Configuration, logger logging.Logger) EventBus {
+	return NewInstrumentedEventBus(newEventBus(configuration, logger))
+}
+
+func newEventBus(configuration config.Configuration, logger logging.Logger) EventBus {
+	mode, err := config.MandatoryString(configuration, mode)
+	if err != nil {
+		logger.Fatalf("invalid eventbus configuration: %v", err)
+	}
+
 	switch mode {
 	case modeInMemory:
-		return inmemory.NewEventBus()
+		return inmemory.NewEventBus(logger)
 	case modeRabbitMQ:
-		return rabbitmq.NewEventBus(configuration)
+		return rabbitmq.NewEventBus(configuration, logger)
 	default:
-		log.Fatalf("unknown eventbus mode: %s", mode)
+		logger.Fatalf("unknown eventbus mode: %s", mode)
 		return nil
 	}
 }
 
 type EventBus interface {
 	io.Closer
+	health.Checker
+	health.LagReporter
 	Publish(ctx context.Context, event domain.Event) error
 	Listen(ctx context.Context, listenerName string, eventHandlers ...domain.EventHandler) error
+	ListenWithOptions(ctx context.Context, listenerName string, options domain.ListenerOptions, eventHandlers ...domain.EventHandler) error
 }
@@ -4,38 +4,145 @@ import (
 	"context"
 	"fmt"
 	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
-	"log"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/worker"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/tracing"
+	"sync"
 )
 
-func NewEventBus() *EventBus {
-	log.Println("starting inmemory eventbus")
+func NewEventBus(logger logging.Logger) *EventBus {
+	logger.Infof("starting inmemory eventbus")
 	return &EventBus{
+		logger:   logger,
+		lock:     &sync.RWMutex{},
 		handlers: make(map[EventKey]HandlerGroups),
+		workers:  make(map[string]*worker.Pool),
 	}
 }
 
 type EventBus struct {
-	handlers map[EventKey]HandlerGroups
+	logger        logging.Logger
+	lock          *sync.RWMutex
+	handlers      map[EventKey]HandlerGroups
+	workers       map[string]*worker.Pool
+	subscriptions int
 }
 
 func (e *EventBus) Close() error {
-	log.Println("closing inmemory eventbus")
+	e.logger.Infof("closing inmemory eventbus")
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for _, workers := range e.workers {
+		workers.Close()
+	}
+	e.workers = make(map[string]*worker.Pool)
+
 	return nil
 }
 
+func (e *EventBus) CheckHealth(ctx context.Context) error {
+	return nil
+}
+
+func (e *EventBus) Lag(ctx context.Context, listenerName string) (int, error) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	if workers, present := e.workers[listenerName]; present {
+		return workers.Pending(), nil
+	}
+
+	return 0, nil
+}
+
 func (e *EventBus) Publish(ctx context.Context, event domain.Event) error {
-	if handlerGroups := e.handlers[eventKey(event.GetDefinition())]; handlerGroups != nil {
-		for _, handler := range handlerGroups.SelectHandlers() {
-			if err := handler.ProcessEvent(event); err != nil {
-				handler.HandleError(event, err)
-			}
+	envelope := domain.NewEnvelope(ctx, event).WithTraceContext(tracing.Inject(ctx))
+
+	for _, delivery := range e.deliveriesOf(envelope) {
+		if err := e.dispatch(ctx, delivery, envelope); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type delivery struct {
+	listenerName string
+	handler      domain.EventHandler
+	workers      *worker.Pool
+}
+
+// deliveriesOf selects the handlers of the envelope under the lock, which is
+// released before dispatching so that handlers can publish or stop listening.
+func (e *EventBus) deliveriesOf(envelope domain.Envelope) []delivery {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	handlerGroups := e.handlers[eventKey(envelope.GetDefinition())]
+	deliveries := make([]delivery, 0, len(handlerGroups))
+	for listenerName, handler := range handlerGroups.SelectHandlers() {
+		deliveries = append(deliveries, delivery{
+			listenerName: listenerName,
+			handler:      handler,
+			workers:      e.workers[listenerName],
+		})
+	}
+
+	return deliveries
+}
+
+func (e *EventBus) dispatch(ctx context.Context, delivery delivery, envelope domain.Envelope) error {
+	listenerName, handler := delivery.listenerName, delivery.handler
+	logger := e.logger.
+		With(logging.Listener, listenerName).
+		With(logging.EventId, envelope.Metadata.EventId).
+		With(logging.EventName, envelope.Metadata.Name)
+	process := func() {
+		decoded, err := domain.DecodeEnvelope(envelope, handler.GetEventDefinition())
+		if err != nil {
+			logger.Errorf("could not decode event: %v", err)
+			handler.HandleError(envelope, err)
+			return
 		}
+
+		if err := handler.ProcessEvent(decoded); err != nil {
+			logger.Warnf("could not process event: %v", err)
+			handler.HandleError(decoded, err)
+			return
+		}
+
+		logger.Debugf("processed event")
+	}
+
+	if delivery.workers == nil {
+		process()
+		return nil
+	}
+
+	if err := delivery.workers.Submit(ctx, envelope.Metadata.EntityId, process); err != nil {
+		return fmt.Errorf("could not dispatch event to listener %s: %w", listenerName, err)
 	}
 
 	return nil
 }
 
 func (e *EventBus) Listen(ctx context.Context, listenerName string, handlers ...domain.EventHandler) error {
+	return e.ListenWithOptions(ctx, listenerName, domain.ListenerOptions{}, handlers...)
+}
+
+func (e *EventBus) ListenWithOptions(
+	ctx context.Context,
+	listenerName string,
+	options domain.ListenerOptions,
+	handlers ...domain.EventHandler,
+) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.subscriptions++
+	subscription := e.subscriptions
 	for _, handler := range handlers {
 		key := eventKey(handler.GetEventDefinition())
 		handlerGroups := e.handlers[key]
@@ -43,13 +150,49 @@ func (e *EventBus) Listen(ctx context.Context, listenerName string, handlers ...
 			handlerGroups = NewHandlerGroups()
 		}
 
-		handlerGroups.AddEventHandler(listenerName, handler)
+		handlerGroups.AddEventHandler(listenerName, subscription, handler)
 		e.handlers[key] = handlerGroups
 	}
 
+	if options.Concurrency > 0 && e.workers[listenerName] == nil {
+		e.workers[listenerName] = worker.NewPool(options)
+	}
+
+	if ctx.Done() != nil {
+		go e.unsubscribeWhenDone(ctx, listenerName, subscription)
+	}
+
 	return nil
 }
 
+func (e *EventBus) unsubscribeWhenDone(ctx context.Context, listenerName string, subscription int) {
+	<-ctx.Done()
+	e.logger.With(logging.Listener, listenerName).Infof("stopping inmemory listener")
+
+	e.lock.Lock()
+	listening := false
+	for key, handlerGroups := range e.handlers {
+		handlerGroups.RemoveSubscription(listenerName, subscription)
+		if _, present := handlerGroups[listenerName]; present {
+			listening = true
+		}
+
+		if len(handlerGroups) == 0 {
+			delete(e.handlers, key)
+		}
+	}
+
+	workers, present := e.workers[listenerName]
+	if present && !listening {
+		delete(e.workers, listenerName)
+	}
+	e.lock.Unlock()
+
+	if present && !listening {
+		workers.Close()
+	}
+}
+
 type EventKey string
 
 func eventKey(event domain.EventDefinition) EventKey {
@@ -5,10 +5,15 @@ import (
 	"math/rand"
 )
 
-type HandlerGroup []domain.EventHandler
+type subscribedHandler struct {
+	subscription int
+	handler      domain.EventHandler
+}
+
+type HandlerGroup []subscribedHandler
 
 func (g HandlerGroup) RandomHandler() domain.EventHandler {
-	return g[int(rand.Uint32())%len(g)]
+	return g[int(rand.Uint32())%len(g)].handler
 }
 
 func NewHandlerGroups() HandlerGroups {
@@ -17,14 +22,33 @@ func NewHandlerGroups() HandlerGroups {
 
 type HandlerGroups map[string]HandlerGroup
 
-func (h HandlerGroups) AddEventHandler(name string, handler domain.EventHandler) {
-	h[name] = append(h[name], handler)
+func (h HandlerGroups) AddEventHandler(name string, subscription int, handler domain.EventHandler) {
+	h[name] = append(h[name], subscribedHandler{
+		subscription: subscription,
+		handler:      handler,
+	})
+}
+
+func (h HandlerGroups) RemoveSubscription(name string, subscription int) {
+	remaining := make(HandlerGroup, 0, len(h[name]))
+	for _, subscribed := range h[name] {
+		if subscribed.subscription != subscription {
+			remaining = append(remaining, subscribed)
+		}
+	}
+
+	if len(remaining) == 0 {
+		delete(h, name)
+		return
+	}
+
+	h[name] = remaining
 }
 
-func (h HandlerGroups) SelectHandlers() []domain.EventHandler {
-	selectedHandlers := make([]domain.EventHandler, 0, 2)
-	for _, handlerGroup := range h {
-		selectedHandlers = append(selectedHandlers, handlerGroup.RandomHandler())
+func (h HandlerGroups) SelectHandlers() map[string]domain.EventHandler {
+	selectedHandlers := make(map[string]domain.EventHandler, len(h))
+	for name, handlerGroup := range h {
+		selectedHandlers[name] = handlerGroup.RandomHandler()
 	}
 
 	return selectedHandlers
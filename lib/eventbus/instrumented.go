@@ -0,0 +1,111 @@
+package eventbus
+
+import (
+	"context"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/metrics"
+	"github.com/frederic-gendebien/pact-poc/lib/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+var (
+	publishedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "eventbus_published_events_total",
+		Help: "Number of published events by outcome.",
+	}, []string{"domain", "name", "outcome"})
+	publishDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "eventbus_publish_duration_seconds",
+		Help:    "Duration of event publications.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"domain", "name"})
+	processedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "eventbus_processed_events_total",
+		Help: "Number of events processed by handlers by outcome.",
+	}, []string{"listener", "domain", "name", "outcome"})
+	processDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "eventbus_process_duration_seconds",
+		Help:    "Duration of event processing by handlers.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"listener", "domain", "name"})
+)
+
+func NewInstrumentedEventBus(eventBus EventBus) *InstrumentedEventBus {
+	return &InstrumentedEventBus{
+		EventBus: eventBus,
+	}
+}
+
+type InstrumentedEventBus struct {
+	EventBus
+}
+
+// Publish continues the trace carried by the envelope, if any, and passes the
+// producer span on to the consumers through the envelope.
+func (i *InstrumentedEventBus) Publish(ctx context.Context, event domain.Event) error {
+	envelope := domain.NewEnvelope(ctx, event)
+	definition := envelope.GetDefinition()
+	ctx, span := tracing.Start(tracing.Extract(ctx, envelope.Metadata.TraceContext), definition.GetDomain()+" publish", trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(
+		attribute.String("messaging.destination", definition.GetDomain()),
+		attribute.String("messaging.event", definition.GetName()),
+		attribute.String("messaging.entity_id", envelope.GetEntityId()),
+	))
+	envelope.Metadata.TraceContext = tracing.Inject(ctx)
+
+	start := time.Now()
+	err := i.EventBus.Publish(ctx, envelope)
+	tracing.End(span, err)
+
+	publishDuration.WithLabelValues(definition.GetDomain(), definition.GetName()).Observe(time.Since(start).Seconds())
+	publishedEvents.WithLabelValues(definition.GetDomain(), definition.GetName(), metrics.Outcome(err)).Inc()
+
+	return err
+}
+
+func (i *InstrumentedEventBus) Listen(ctx context.Context, listenerName string, eventHandlers ...domain.EventHandler) error {
+	return i.EventBus.Listen(ctx, listenerName, instrumentedHandlers(listenerName, eventHandlers)...)
+}
+
+func (i *InstrumentedEventBus) ListenWithOptions(ctx context.Context, listenerName string, options domain.ListenerOptions, eventHandlers ...domain.EventHandler) error {
+	return i.EventBus.ListenWithOptions(ctx, listenerName, options, instrumentedHandlers(listenerName, eventHandlers)...)
+}
+
+func instrumentedHandlers(listenerName string, eventHandlers []domain.EventHandler) []domain.EventHandler {
+	instrumented := make([]domain.EventHandler, 0, len(eventHandlers))
+	for _, eventHandler := range eventHandlers {
+		instrumented = append(instrumented, instrumentedHandler{
+			EventHandler: eventHandler,
+			listenerName: listenerName,
+		})
+	}
+
+	return instrumented
+}
+
+type instrumentedHandler struct {
+	domain.EventHandler
+	listenerName string
+}
+
+func (i instrumentedHandler) ProcessEvent(envelope domain.Envelope) error {
+	definition := i.GetEventDefinition()
+	ctx, span := tracing.Start(CausedBy(context.Background(), envelope), definition.GetDomain()+" process", trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(
+		attribute.String("messaging.destination", definition.GetDomain()),
+		attribute.String("messaging.event", definition.GetName()),
+		attribute.String("messaging.message_id", envelope.Metadata.EventId),
+		attribute.String("messaging.consumer", i.listenerName),
+	))
+	envelope.Metadata.TraceContext = tracing.Inject(ctx)
+
+	start := time.Now()
+	err := i.EventHandler.ProcessEvent(envelope)
+	tracing.End(span, err)
+
+	processDuration.WithLabelValues(i.listenerName, definition.GetDomain(), definition.GetName()).Observe(time.Since(start).Seconds())
+	processedEvents.WithLabelValues(i.listenerName, definition.GetDomain(), definition.GetName(), metrics.Outcome(err)).Inc()
+
+	return err
+}
@@ -0,0 +1,133 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/inmemory"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/metrics"
+	"github.com/frederic-gendebien/pact-poc/lib/tracing"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+type unreachableEventBus struct {
+	EventBus
+}
+
+func (u unreachableEventBus) Publish(ctx context.Context, event domain.Event) error {
+	return errors.New("broker unreachable")
+}
+
+type failingHandler struct {
+	recordingHandler
+}
+
+func (f failingHandler) ProcessEvent(envelope domain.Envelope) error {
+	return errors.New("could not process")
+}
+
+func TestInstrumentedEventBus_CountsPublishedEvents(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewDiscardLogger()
+	succeeded := publishedEvents.WithLabelValues("test", "happened", metrics.OutcomeSuccess)
+	failed := publishedEvents.WithLabelValues("test", "happened", metrics.OutcomeFailure)
+	successes, failures := testutil.ToFloat64(succeeded), testutil.ToFloat64(failed)
+
+	if err := NewInstrumentedEventBus(inmemory.NewEventBus(logger)).Publish(ctx, happened{Id: "published"}); err != nil {
+		t.Fatalf("could not publish: %v", err)
+	}
+
+	if err := NewInstrumentedEventBus(unreachableEventBus{}).Publish(ctx, happened{Id: "lost"}); err == nil {
+		t.Fatalf("expected publication to fail")
+	}
+
+	if published := testutil.ToFloat64(succeeded) - successes; published != 1 {
+		t.Fatalf("expected 1 successful publication, but got: %v", published)
+	}
+
+	if published := testutil.ToFloat64(failed) - failures; published != 1 {
+		t.Fatalf("expected 1 failed publication, but got: %v", published)
+	}
+}
+
+func TestInstrumentedEventBus_CountsProcessedEvents(t *testing.T) {
+	ctx := context.Background()
+	eventBus := NewInstrumentedEventBus(inmemory.NewEventBus(logging.NewDiscardLogger()))
+	processed := make([]string, 0)
+	if err := eventBus.Listen(ctx, "recording", recordingHandler{processed: &processed}); err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+
+	if err := eventBus.Listen(ctx, "failing", failingHandler{}); err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+
+	succeeded := processedEvents.WithLabelValues("recording", "test", "happened", metrics.OutcomeSuccess)
+	failed := processedEvents.WithLabelValues("failing", "test", "happened", metrics.OutcomeFailure)
+	successes, failures := testutil.ToFloat64(succeeded), testutil.ToFloat64(failed)
+
+	if err := eventBus.Publish(ctx, happened{Id: "published"}); err != nil {
+		t.Fatalf("could not publish: %v", err)
+	}
+
+	if count := testutil.ToFloat64(succeeded) - successes; count != 1 {
+		t.Fatalf("expected 1 successful processing, but got: %v", count)
+	}
+
+	if count := testutil.ToFloat64(failed) - failures; count != 1 {
+		t.Fatalf("expected 1 failed processing, but got: %v", count)
+	}
+}
+
+type tracingHandler struct {
+	recordingHandler
+	traces *[]trace.SpanContext
+}
+
+func (t tracingHandler) ProcessEvent(envelope domain.Envelope) error {
+	*t.traces = append(*t.traces, trace.SpanContextFromContext(CausedBy(context.Background(), envelope)))
+	return nil
+}
+
+func TestInstrumentedEventBus_PropagatesTraces(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	eventBus := NewInstrumentedEventBus(inmemory.NewEventBus(logging.NewDiscardLogger()))
+	traces := make([]trace.SpanContext, 0)
+	if err := eventBus.Listen(context.Background(), "tracing", tracingHandler{traces: &traces}); err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+
+	requestCtx, request := tracing.Start(context.Background(), "request")
+	pending := NewEnvelope(requestCtx, happened{Id: "pending"})
+	request.End()
+
+	if err := eventBus.Publish(context.Background(), pending); err != nil {
+		t.Fatalf("could not publish: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected request, consumer and producer spans, but got: %d", len(spans))
+	}
+
+	consumer, producer := spans[1], spans[2]
+	if producer.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Fatalf("expected producer span to continue the trace of the envelope, but got parent: %s", producer.Parent().SpanID())
+	}
+
+	if consumer.Parent().SpanID() != producer.SpanContext().SpanID() {
+		t.Fatalf("expected consumer span to be parented by the producer span, but got: %s", consumer.Parent().SpanID())
+	}
+
+	if len(traces) != 1 || traces[0].SpanID() != consumer.SpanContext().SpanID() {
+		t.Fatalf("expected handler to run within the consumer span, but got: %v", traces)
+	}
+}
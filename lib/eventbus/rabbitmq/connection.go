@@ -0,0 +1,203 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/streadway/amqp"
+	"sync"
+	"time"
+)
+
+var (
+	ErrClosed = errors.New("rabbitmq eventbus is closed")
+)
+
+type State int
+
+const (
+	StateConnecting State = iota
+	StateConnected
+	StateDisconnected
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+type backoff struct {
+	initialDelay time.Duration
+	maxDelay     time.Duration
+}
+
+func (b backoff) delay(attempt int) time.Duration {
+	delay := b.initialDelay
+	for i := 1; i < attempt && delay < b.maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > b.maxDelay {
+		return b.maxDelay
+	}
+
+	return delay
+}
+
+func newConnector(url string, backoff backoff, logger logging.Logger) *connector {
+	c := &connector{
+		url:       url,
+		backoff:   backoff,
+		logger:    logger,
+		lock:      &sync.RWMutex{},
+		state:     StateConnecting,
+		connected: make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	go c.connect()
+
+	return c
+}
+
+type connector struct {
+	url        string
+	backoff    backoff
+	logger     logging.Logger
+	lock       *sync.RWMutex
+	state      State
+	connection *amqp.Connection
+	generation int
+	connected  chan struct{}
+	closed     chan struct{}
+}
+
+func (c *connector) State() State {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.state
+}
+
+func (c *connector) Connection(ctx context.Context) (*amqp.Connection, int, error) {
+	for {
+		c.lock.RLock()
+		state, connection, generation, connected := c.state, c.connection, c.generation, c.connected
+		c.lock.RUnlock()
+
+		switch state {
+		case StateConnected:
+			return connection, generation, nil
+		case StateClosed:
+			return nil, generation, ErrClosed
+		}
+
+		select {
+		case <-connected:
+		case <-c.closed:
+			return nil, generation, ErrClosed
+		case <-ctx.Done():
+			return nil, generation, ctx.Err()
+		}
+	}
+}
+
+// rotate changes the url, and so the credentials, used by the next connection.
+// The current connection stays open since the broker authenticated it already.
+func (c *connector) rotate(url string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.url = url
+	c.logger.Infof("rabbitmq url rotated, it will be used from the next connection")
+}
+
+func (c *connector) currentURL() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.url
+}
+
+func (c *connector) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.state == StateClosed {
+		return nil
+	}
+
+	c.state = StateClosed
+	close(c.closed)
+	if c.connection != nil {
+		return c.connection.Close()
+	}
+
+	return nil
+}
+
+func (c *connector) connect() {
+	for attempt := 1; ; attempt++ {
+		connection, err := amqp.Dial(c.currentURL())
+		if err == nil {
+			if c.connectedWith(connection) {
+				go c.watch(connection)
+			}
+			return
+		}
+
+		delay := c.backoff.delay(attempt)
+		c.logger.Warnf("could not connect to rabbitmq, retrying in %v: %v", delay, err)
+		select {
+		case <-time.After(delay):
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+func (c *connector) connectedWith(connection *amqp.Connection) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.state == StateClosed {
+		_ = connection.Close()
+		return false
+	}
+
+	c.logger.Infof("connected to rabbitmq")
+	c.state = StateConnected
+	c.connection = connection
+	c.generation++
+	close(c.connected)
+
+	return true
+}
+
+func (c *connector) watch(connection *amqp.Connection) {
+	err := <-connection.NotifyClose(make(chan *amqp.Error, 1))
+
+	c.lock.Lock()
+	if c.state == StateClosed {
+		c.lock.Unlock()
+		return
+	}
+
+	c.logger.Errorf("lost connection to rabbitmq: %v", err)
+	c.state = StateDisconnected
+	c.connection = nil
+	c.connected = make(chan struct{})
+	c.lock.Unlock()
+
+	c.connect()
+}
@@ -0,0 +1,150 @@
+package rabbitmq
+
+import (
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/streadway/amqp"
+	"sync"
+	"time"
+)
+
+const (
+	EventAttempt         = "event.attempt"
+	EventFailureReason   = "event.failure.reason"
+	EventFailureListener = "event.failure.listener"
+	EventFailureTime     = "event.failure.time"
+)
+
+func newConsumer(channel *amqp.Channel, logger logging.Logger, queueName string, policy RetryPolicy, eventHandlers ...domain.EventHandler) *consumer {
+	return &consumer{
+		channel:   channel,
+		logger:    logger,
+		queueName: queueName,
+		lock:      &sync.RWMutex{},
+		policy:    policy,
+		handlers:  handlerMap(eventHandlers...),
+	}
+}
+
+type consumer struct {
+	channel   *amqp.Channel
+	logger    logging.Logger
+	queueName string
+	lock      *sync.RWMutex
+	policy    RetryPolicy
+	handlers  map[string]domain.EventHandler
+}
+
+func (c *consumer) retryPolicy() RetryPolicy {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.policy
+}
+
+func (c *consumer) setRetryPolicy(policy RetryPolicy) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.policy = policy
+}
+
+func handlerMap(handlers ...domain.EventHandler) map[string]domain.EventHandler {
+	m := make(map[string]domain.EventHandler)
+	for _, eventHandler := range handlers {
+		m[eventHandler.GetEventDefinition().GetName()] = eventHandler
+	}
+
+	return m
+}
+
+func (c *consumer) processMessage(message amqp.Delivery) {
+	metadata := metadataFrom(message)
+	logger := c.logger.
+		With(logging.EventId, metadata.EventId).
+		With(logging.EventName, metadata.Name).
+		With(logging.CorrelationId, metadata.CorrelationId)
+	handler, present := c.handlers[metadata.Name]
+	if !present {
+		logger.Debugf("skip message from domain (%s)", metadata.Domain)
+		_ = message.Ack(false)
+		return
+	}
+
+	envelope, err := envelopeFrom(message, metadata, handler.GetEventDefinition())
+	if err != nil {
+		logger.Errorf("could not unmarshal event: %v", err)
+		c.deadLetter(logger, message, handler, envelope, err)
+		return
+	}
+
+	if err := handler.ProcessEvent(envelope); err != nil {
+		logger.Warnf("could not process message from domain (%s): %v", metadata.Domain, err)
+		c.retryOrDeadLetter(logger, message, handler, envelope, err)
+		return
+	}
+
+	_ = message.Ack(false)
+}
+
+func envelopeFrom(message amqp.Delivery, metadata domain.Metadata, definition domain.EventDefinition) (domain.Envelope, error) {
+	return domain.DecodeEnvelope(domain.Envelope{
+		Metadata: metadata,
+		Event:    domain.NewRawEvent(metadata.Domain, metadata.Name, metadata.EntityId, message.Body),
+	}, definition)
+}
+
+func (c *consumer) retryOrDeadLetter(logger logging.Logger, message amqp.Delivery, handler domain.EventHandler, envelope domain.Envelope, cause error) {
+	attempt := intHeader(message.Headers, EventAttempt, 1)
+	policy := c.retryPolicy()
+	if !policy.CanRetry(attempt) {
+		c.deadLetter(logger, message, handler, envelope, cause)
+		return
+	}
+
+	delay := policy.Delay(attempt)
+	logger.Infof("retrying event in %v, attempt %d/%d", delay, attempt+1, policy.MaxAttempts)
+	headers := copyHeaders(message.Headers)
+	headers[EventAttempt] = int32(attempt + 1)
+	retriedEvents.WithLabelValues(c.queueName, envelope.Metadata.Domain, envelope.Metadata.Name).Inc()
+	c.republish(logger, message, retryExchangeName(c.queueName), retryRoutingKey(delay), headers)
+}
+
+func (c *consumer) deadLetter(logger logging.Logger, message amqp.Delivery, handler domain.EventHandler, envelope domain.Envelope, cause error) {
+	logger.Errorf("dead lettering event: %v", cause)
+	handler.HandleError(envelope, cause)
+	headers := copyHeaders(message.Headers)
+	headers[EventFailureReason] = cause.Error()
+	headers[EventFailureListener] = c.queueName
+	headers[EventFailureTime] = time.Now().UTC().Format(time.RFC3339Nano)
+	deadLetteredEvents.WithLabelValues(c.queueName, envelope.Metadata.Domain, envelope.Metadata.Name).Inc()
+	c.republish(logger, message, deadLetterExchangeName(c.queueName), "", headers)
+}
+
+func (c *consumer) republish(logger logging.Logger, message amqp.Delivery, exchange string, key string, headers amqp.Table) {
+	if err := c.channel.Publish(exchange, key, false, false, amqp.Publishing{
+		Headers:       headers,
+		ContentType:   message.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		MessageId:     message.MessageId,
+		CorrelationId: message.CorrelationId,
+		Timestamp:     message.Timestamp,
+		Type:          message.Type,
+		Body:          message.Body,
+	}); err != nil {
+		logger.Errorf("could not republish event to exchange (%s): %v", exchange, err)
+		_ = message.Nack(false, true)
+		return
+	}
+
+	_ = message.Ack(false)
+}
+
+func copyHeaders(headers amqp.Table) amqp.Table {
+	copied := make(amqp.Table, len(headers)+4)
+	for key, value := range headers {
+		copied[key] = value
+	}
+
+	return copied
+}
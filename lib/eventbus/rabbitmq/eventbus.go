@@ -3,78 +3,273 @@ package rabbitmq
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/frederic-gendebien/pact-poc/lib/config/layered"
 	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/worker"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/frederic-gendebien/pact-poc/lib/tracing"
 	"github.com/streadway/amqp"
-	"log"
+	"time"
 )
 
 const (
-	url = "RABBITMQ_URL"
+	url                   = "RABBITMQ_URL"
+	reconnectInitialDelay = "RABBITMQ_RECONNECT_INITIAL_DELAY"
+	reconnectMaxDelay     = "RABBITMQ_RECONNECT_MAX_DELAY"
+	publishChannels       = "RABBITMQ_PUBLISH_CHANNELS"
 )
 
-func NewEventBus(configuration config.Configuration) *EventBus {
-	log.Println("connecting to rabbitmq")
-	url := configuration.GetStringOrCrash(url)
-	conn, err := amqp.Dial(url)
+var (
+	errDeliveriesClosed = errors.New("no more message available")
+)
+
+// Properties describes the configuration of the rabbitmq event bus. The
+// listener properties can be overridden per listener, see listenerPropertyName.
+func Properties() []config.Property {
+	return []config.Property{
+		{Name: url, Type: config.TypeURL, Required: true, Description: "amqp url of the broker"},
+		{Name: reconnectInitialDelay, Type: config.TypeDuration, Default: "500ms", Description: "delay before the first reconnection attempt"},
+		{Name: reconnectMaxDelay, Type: config.TypeDuration, Default: "30s", Description: "upper bound of the delay between two reconnection attempts"},
+		{Name: publishChannels, Type: config.TypeInt, Default: "8", Description: "number of channels used to publish"},
+		{Name: propertyName(listenerConcurrency), Type: config.TypeInt, Default: defaultListenerConcurrency, Description: "number of workers per listener"},
+		{Name: propertyName(listenerPrefetch), Type: config.TypeInt, Default: defaultListenerPrefetch, Description: "prefetch count per listener, 0 derives it from the concurrency"},
+		{Name: propertyName(listenerOrderedByEntity), Type: config.TypeBool, Default: defaultListenerOrderedByEntity, Description: "keeps the events of an entity ordered across workers"},
+		{Name: propertyName(retryMaxAttempts), Type: config.TypeInt, Default: defaultRetryMaxAttempts, Description: "attempts before an event is dead lettered"},
+		{Name: propertyName(retryInitialDelay), Type: config.TypeDuration, Default: defaultRetryInitialDelay, Description: "delay before the first redelivery"},
+		{Name: propertyName(retryMaxDelay), Type: config.TypeDuration, Default: defaultRetryMaxDelay, Description: "upper bound of the delay between two redeliveries"},
+		{Name: propertyName(retryMultiplier), Type: config.TypeFloat, Default: defaultRetryMultiplier, Description: "growth factor of the delay between two redeliveries"},
+	}
+}
+
+func NewEventBus(configuration config.Configuration, logger logging.Logger) *EventBus {
+	logger.Infof("connecting to rabbitmq")
+	url, err := config.MandatoryString(configuration, url)
 	if err != nil {
-		log.Fatalf("could not connect to rabbitmq: %v", err)
+		logger.Fatalf("invalid rabbitmq configuration: %v", err)
 	}
 
+	reconnect, err := reconnectBackoff(configuration)
+	if err != nil {
+		logger.Fatalf("invalid rabbitmq configuration: %v", err)
+	}
+
+	channels, err := config.Int(configuration, publishChannels)
+	if err != nil {
+		logger.Fatalf("invalid rabbitmq configuration: %v", err)
+	}
+
+	connector := newConnector(url, reconnect, logger)
+
 	return &EventBus{
-		connection: conn,
+		configuration: configuration,
+		logger:        logger,
+		connector:     connector,
+		publishers:    newChannelPool(connector, channels),
+		unsubscribe:   rotateOnChange(configuration, connector),
 	}
 }
 
+func rotateOnChange(configuration config.Configuration, connector *connector) func() {
+	return config.OnChange(configuration, func(changes []layered.Change) {
+		if rotated := changes[0].Current; rotated != "" {
+			connector.rotate(rotated)
+		}
+	}, url)
+}
+
+func reconnectBackoff(configuration config.Configuration) (backoff, error) {
+	initialDelay, err := config.Duration(configuration, reconnectInitialDelay)
+	if err != nil {
+		return backoff{}, err
+	}
+
+	maxDelay, err := config.Duration(configuration, reconnectMaxDelay)
+	if err != nil {
+		return backoff{}, err
+	}
+
+	return backoff{
+		initialDelay: initialDelay,
+		maxDelay:     maxDelay,
+	}, nil
+}
+
 type EventBus struct {
-	connection *amqp.Connection
+	configuration config.Configuration
+	logger        logging.Logger
+	connector     *connector
+	publishers    *channelPool
+	unsubscribe   func()
 }
 
 func (e *EventBus) Close() error {
-	log.Println("closing rabbitmq eventbus")
-	return e.connection.Close()
+	e.logger.Infof("closing rabbitmq eventbus")
+	e.unsubscribe()
+	_ = e.publishers.Close()
+	return e.connector.Close()
+}
+
+func (e *EventBus) State() State {
+	return e.connector.State()
+}
+
+func (e *EventBus) IsConnected() bool {
+	return e.State() == StateConnected
+}
+
+func (e *EventBus) CheckHealth(ctx context.Context) error {
+	if state := e.State(); state != StateConnected {
+		return fmt.Errorf("rabbitmq is %s", state)
+	}
+
+	return nil
+}
+
+func (e *EventBus) Lag(ctx context.Context, listenerName string) (int, error) {
+	connection, _, err := e.connector.Connection(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	channel, err := connection.Channel()
+	if err != nil {
+		return 0, err
+	}
+	defer channel.Close()
+
+	queue, err := channel.QueueInspect(listenerName)
+	if err != nil {
+		return 0, err
+	}
+
+	return queue.Messages, nil
 }
 
 func (e *EventBus) Publish(ctx context.Context, event domain.Event) error {
-	payload, err := json.Marshal(event.GetPayload())
+	envelope := domain.NewEnvelope(ctx, event).WithTraceContext(tracing.Inject(ctx))
+	payload, err := json.Marshal(envelope.GetPayload())
 	if err != nil {
 		return err
 	}
 
-	channel, err := e.connection.Channel()
+	channel, err := e.publishers.acquire(ctx)
 	if err != nil {
 		return err
 	}
 
-	defer channel.Close()
+	if err := e.publishers.declareTopic(channel, envelope.GetDefinition()); err != nil {
+		e.publishers.discard(channel)
+		return err
+	}
+
+	if err := channel.publish(ctx, envelope, payload); err != nil {
+		if errors.Is(err, UnroutableError{}) || errors.Is(err, NackError{}) {
+			e.publishers.release(channel)
+		} else {
+			e.publishers.discard(channel)
+		}
 
-	if err := createTopic(channel, event.GetDefinition()); err != nil {
 		return err
 	}
 
-	return channel.Publish(
-		event.GetDefinition().GetDomain(), //exchange
-		event.GetDefinition().GetName(),   //key
-		true,                              //mandatory
-		false,                             //immediate
-		amqp.Publishing{
-			Headers:     newHeaders(event.GetDefinition()),
-			ContentType: "application/json",
-			Body:        payload,
-		},
-	)
+	e.publishers.release(channel)
+
+	return nil
 }
 
 func (e *EventBus) Listen(ctx context.Context, listenerName string, eventHandlers ...domain.EventHandler) error {
-	channel, err := e.connection.Channel()
+	options, err := listenerOptionsFor(e.configuration, listenerName)
+	if err != nil {
+		return err
+	}
+
+	return e.listen(ctx, listenerName, options, true, eventHandlers...)
+}
+
+func (e *EventBus) ListenWithOptions(
+	ctx context.Context,
+	listenerName string,
+	options domain.ListenerOptions,
+	eventHandlers ...domain.EventHandler,
+) error {
+	return e.listen(ctx, listenerName, options, false, eventHandlers...)
+}
+
+func (e *EventBus) listen(
+	ctx context.Context,
+	listenerName string,
+	options domain.ListenerOptions,
+	configured bool,
+	eventHandlers ...domain.EventHandler,
+) error {
+	policy, err := retryPolicyFor(e.configuration, listenerName)
+	if err != nil {
+		return err
+	}
+
+	backoff, err := reconnectBackoff(e.configuration)
+	if err != nil {
+		return err
+	}
+
+	logger := e.logger.With(logging.Listener, listenerName)
+	for attempt := 1; ; attempt++ {
+		connection, _, err := e.connector.Connection(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = e.consume(ctx, connection, logger, listenerName, options, configured, policy, eventHandlers...)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var restart restartError
+		if errors.As(err, &restart) {
+			logger.Infof("listener consuming again: %v", err)
+			options, attempt = restart.options, 0
+			continue
+		}
+
+		if errors.Is(err, errDeliveriesClosed) {
+			attempt = 0
+		}
+
+		delay := backoff.delay(attempt + 1)
+		logger.Warnf("listener stopped consuming, resuming in %v: %v", delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (e *EventBus) consume(
+	ctx context.Context,
+	connection *amqp.Connection,
+	logger logging.Logger,
+	listenerName string,
+	options domain.ListenerOptions,
+	configured bool,
+	policy RetryPolicy,
+	eventHandlers ...domain.EventHandler,
+) error {
+	channel, err := connection.Channel()
 	if err != nil {
 		return err
 	}
 
 	defer channel.Close()
 
-	queueName, err := createQueue(channel, listenerName, eventHandlers...)
+	if err := channel.Qos(options.PrefetchCount(), 0, false); err != nil {
+		return err
+	}
+
+	queueName, err := createQueue(channel, listenerName, policy, eventHandlers...)
 	if err != nil {
 		return err
 	}
@@ -84,40 +279,86 @@ func (e *EventBus) Listen(ctx context.Context, listenerName string, eventHandler
 		return err
 	}
 
-	handlers := handlerMap(eventHandlers...)
-	for message := range messages {
-		processMessage(message, handlers)
+	logger.Infof("listener consuming queue (%s) with %d worker(s)", queueName, options.Workers())
+	consumer := newConsumer(channel, logger, queueName, policy, eventHandlers...)
+	unsubscribe := e.reconfigureOnChange(logger, channel, consumer, listenerName)
+	defer unsubscribe()
+
+	restarts := make(chan domain.ListenerOptions, 1)
+	if configured {
+		unsubscribe := e.restartOnPrefetchChange(logger, listenerName, options, restarts)
+		defer unsubscribe()
+	}
+
+	workers := worker.NewPool(options)
+	defer workers.Close()
+
+	consuming, stop := withChannelClose(ctx, channel)
+	defer stop()
+
+	for {
+		select {
+		case <-consuming.Done():
+			return consumingError(ctx)
+		case updated := <-restarts:
+			return newRestartError(updated)
+		case message, ok := <-messages:
+			if !ok {
+				return errDeliveriesClosed
+			}
+
+			if err := workers.Submit(consuming, stringHeader(message.Headers, EventEntityId), func() {
+				consumer.processMessage(message)
+			}); err != nil {
+				return consumingError(ctx)
+			}
+		}
 	}
+}
+
+// withChannelClose returns a context done when ctx is or when the channel
+// closes, so a consumer waiting for a free worker notices both.
+func withChannelClose(ctx context.Context, channel *amqp.Channel) (context.Context, context.CancelFunc) {
+	consuming, cancel := context.WithCancel(ctx)
+	closed := channel.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		select {
+		case <-closed:
+			cancel()
+		case <-consuming.Done():
+		}
+	}()
+
+	return consuming, cancel
+}
 
-	return fmt.Errorf("no more message available")
+// restartError stops consuming so that the listener consumes again with the
+// updated options. Leaving the consume loop drains the worker pool and closes
+// the channel, which gives the messages prefetched but not processed back.
+type restartError struct {
+	options domain.ListenerOptions
 }
 
-func handlerMap(handlers ...domain.EventHandler) map[string]domain.EventHandler {
-	m := make(map[string]domain.EventHandler)
-	for _, eventHandler := range handlers {
-		m[eventHandler.GetEventDefinition().GetName()] = eventHandler
+func newRestartError(options domain.ListenerOptions) restartError {
+	return restartError{
+		options: options,
 	}
+}
 
-	return m
+func (r restartError) Error() string {
+	return fmt.Sprintf("prefetch changed to %d", r.options.PrefetchCount())
 }
 
-func processMessage(message amqp.Delivery, handlers map[string]domain.EventHandler) {
-	messageDomain := message.Headers[EventDomain].(string)
-	messageType := message.Headers[EventType].(string)
-	if handler, present := handlers[messageType]; present {
-		event := handler.GetEventDefinition().GetType()
-		if err := json.Unmarshal(message.Body, event); err != nil {
-			log.Printf("could not unmarshal event: %v", err)
-			handler.HandleError(message.Body, err)
-		}
+func (r restartError) Is(err error) bool {
+	_, ok := err.(restartError)
 
-		if err := handler.ProcessEvent(event); err != nil {
-			log.Printf("could not process message from domain (%s) of type (%s): %v", messageDomain, messageType, err)
-			handler.HandleError(event, err)
-		}
-	} else {
-		log.Printf("skip message from domain (%s) of type (%s)", messageDomain, messageType)
+	return ok
+}
+
+func consumingError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	_ = message.Ack(false)
+	return errDeliveriesClosed
 }
@@ -1,18 +1,112 @@
 package rabbitmq
 
 import (
+	"fmt"
 	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/tracing"
 	"github.com/streadway/amqp"
+	"time"
 )
 
 const (
-	EventDomain = "event.domain"
-	EventType   = "event.type"
+	EventDomain        = "event.domain"
+	EventType          = "event.type"
+	EventEntityId      = "event.entity_id"
+	EventSequence      = "event.sequence"
+	EventOccurredAt    = "event.occurred_at"
+	EventCausationId   = "event.causation_id"
+	EventSchemaVersion = "event.schema_version"
 )
 
-func newHeaders(event domain.EventDefinition) amqp.Table {
+func newHeaders(metadata domain.Metadata) amqp.Table {
 	m := make(map[string]interface{})
-	m[EventDomain] = event.GetDomain()
-	m[EventType] = event.GetName()
+	m[EventDomain] = metadata.Domain
+	m[EventType] = metadata.Name
+	m[EventEntityId] = metadata.EntityId
+	m[EventSequence] = int64(metadata.Sequence)
+	m[EventOccurredAt] = metadata.OccurredAt.Format(time.RFC3339Nano)
+	m[EventCausationId] = metadata.CausationId
+	m[EventSchemaVersion] = int32(metadata.SchemaVersion)
+	for key, value := range metadata.TraceContext {
+		m[key] = value
+	}
 	return m
 }
+
+func newPublishing(metadata domain.Metadata, payload []byte) amqp.Publishing {
+	return amqp.Publishing{
+		Headers:       newHeaders(metadata),
+		ContentType:   "application/json",
+		DeliveryMode:  amqp.Persistent,
+		MessageId:     metadata.EventId,
+		CorrelationId: metadata.CorrelationId,
+		Timestamp:     metadata.OccurredAt,
+		Type:          metadata.Name,
+		Body:          payload,
+	}
+}
+
+func metadataFrom(message amqp.Delivery) domain.Metadata {
+	return domain.Metadata{
+		EventId:       message.MessageId,
+		Domain:        stringHeader(message.Headers, EventDomain),
+		Name:          stringHeader(message.Headers, EventType),
+		EntityId:      stringHeader(message.Headers, EventEntityId),
+		Sequence:      intHeader(message.Headers, EventSequence, 0),
+		OccurredAt:    occurredAt(message),
+		CorrelationId: message.CorrelationId,
+		CausationId:   stringHeader(message.Headers, EventCausationId),
+		SchemaVersion: intHeader(message.Headers, EventSchemaVersion, domain.DefaultSchemaVersion),
+		TraceContext:  traceContextFrom(message.Headers),
+	}
+}
+
+func traceContextFrom(headers amqp.Table) map[string]string {
+	var traceContext map[string]string
+	for _, field := range tracing.Fields() {
+		if value := stringHeader(headers, field); value != "" {
+			if traceContext == nil {
+				traceContext = make(map[string]string)
+			}
+			traceContext[field] = value
+		}
+	}
+
+	return traceContext
+}
+
+func stringHeader(headers amqp.Table, name string) string {
+	switch value := headers[name].(type) {
+	case string:
+		return value
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+func intHeader(headers amqp.Table, name string, defaultValue int) int {
+	switch value := headers[name].(type) {
+	case int:
+		return value
+	case int8:
+		return int(value)
+	case int16:
+		return int(value)
+	case int32:
+		return int(value)
+	case int64:
+		return int(value)
+	default:
+		return defaultValue
+	}
+}
+
+func occurredAt(message amqp.Delivery) time.Time {
+	if occurredAt, err := time.Parse(time.RFC3339Nano, stringHeader(message.Headers, EventOccurredAt)); err == nil {
+		return occurredAt.UTC()
+	}
+
+	return message.Timestamp.UTC()
+}
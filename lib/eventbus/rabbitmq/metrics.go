@@ -0,0 +1,17 @@
+package rabbitmq
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	retriedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rabbitmq_retried_events_total",
+		Help: "Number of events sent to a retry queue.",
+	}, []string{"listener", "domain", "name"})
+	deadLetteredEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rabbitmq_dead_lettered_events_total",
+		Help: "Number of events sent to a dead letter queue.",
+	}, []string{"listener", "domain", "name"})
+)
@@ -0,0 +1,46 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+)
+
+const (
+	listenerConcurrency     = "CONCURRENCY"
+	listenerPrefetch        = "PREFETCH"
+	listenerOrderedByEntity = "ORDERED_BY_ENTITY"
+
+	defaultListenerConcurrency     = "1"
+	defaultListenerPrefetch        = "0"
+	defaultListenerOrderedByEntity = "true"
+)
+
+func listenerOptionsFor(configuration config.Configuration, listenerName string) (domain.ListenerOptions, error) {
+	concurrency, err := config.Int(configuration, listenerPropertyName(listenerName, listenerConcurrency), propertyName(listenerConcurrency))
+	if err != nil {
+		return domain.ListenerOptions{}, fmt.Errorf("invalid concurrency for listener %s: %v", listenerName, err)
+	}
+	if concurrency < 1 {
+		return domain.ListenerOptions{}, fmt.Errorf("invalid concurrency for listener %s: %d is lower than 1", listenerName, concurrency)
+	}
+
+	prefetch, err := config.Int(configuration, listenerPropertyName(listenerName, listenerPrefetch), propertyName(listenerPrefetch))
+	if err != nil {
+		return domain.ListenerOptions{}, fmt.Errorf("invalid prefetch for listener %s: %v", listenerName, err)
+	}
+	if prefetch < 0 {
+		return domain.ListenerOptions{}, fmt.Errorf("invalid prefetch for listener %s: %d is negative", listenerName, prefetch)
+	}
+
+	orderedByEntity, err := config.Bool(configuration, listenerPropertyName(listenerName, listenerOrderedByEntity), propertyName(listenerOrderedByEntity))
+	if err != nil {
+		return domain.ListenerOptions{}, fmt.Errorf("invalid ordered by entity flag for listener %s: %v", listenerName, err)
+	}
+
+	return domain.ListenerOptions{
+		Concurrency:     concurrency,
+		Prefetch:        prefetch,
+		OrderedByEntity: orderedByEntity,
+	}, nil
+}
@@ -0,0 +1,245 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/streadway/amqp"
+	"sync"
+)
+
+func NewUnroutableError(eventId string, returned amqp.Return) UnroutableError {
+	return UnroutableError{
+		EventId:    eventId,
+		Exchange:   returned.Exchange,
+		RoutingKey: returned.RoutingKey,
+		ReplyCode:  returned.ReplyCode,
+		ReplyText:  returned.ReplyText,
+	}
+}
+
+type UnroutableError struct {
+	EventId    string `json:"event_id"`
+	Exchange   string `json:"exchange"`
+	RoutingKey string `json:"routing_key"`
+	ReplyCode  uint16 `json:"reply_code"`
+	ReplyText  string `json:"reply_text"`
+}
+
+func (u UnroutableError) Error() string {
+	return fmt.Sprintf("event %s could not be routed from exchange (%s) with key (%s): %d %s",
+		u.EventId, u.Exchange, u.RoutingKey, u.ReplyCode, u.ReplyText)
+}
+
+func (u UnroutableError) Is(err error) bool {
+	_, ok := err.(UnroutableError)
+
+	return ok
+}
+
+func NewNackError(eventId string, exchange string, routingKey string) NackError {
+	return NackError{
+		EventId:    eventId,
+		Exchange:   exchange,
+		RoutingKey: routingKey,
+	}
+}
+
+type NackError struct {
+	EventId    string `json:"event_id"`
+	Exchange   string `json:"exchange"`
+	RoutingKey string `json:"routing_key"`
+}
+
+func (n NackError) Error() string {
+	return fmt.Sprintf("event %s was rejected by the broker on exchange (%s) with key (%s)", n.EventId, n.Exchange, n.RoutingKey)
+}
+
+func (n NackError) Is(err error) bool {
+	_, ok := err.(NackError)
+
+	return ok
+}
+
+type confirmChannel struct {
+	channel    *amqp.Channel
+	generation int
+	confirms   chan amqp.Confirmation
+	returns    chan amqp.Return
+	closes     chan *amqp.Error
+}
+
+func openConfirmChannel(connection *amqp.Connection, generation int) (*confirmChannel, error) {
+	channel, err := connection.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := channel.Confirm(false); err != nil {
+		_ = channel.Close()
+		return nil, err
+	}
+
+	return &confirmChannel{
+		channel:    channel,
+		generation: generation,
+		confirms:   channel.NotifyPublish(make(chan amqp.Confirmation, 1)),
+		returns:    channel.NotifyReturn(make(chan amqp.Return, 1)),
+		closes:     channel.NotifyClose(make(chan *amqp.Error, 1)),
+	}, nil
+}
+
+func (c *confirmChannel) isClosed() bool {
+	select {
+	case <-c.closes:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *confirmChannel) publish(ctx context.Context, envelope domain.Envelope, payload []byte) error {
+	exchange, key := envelope.Metadata.Domain, envelope.Metadata.Name
+	if err := c.channel.Publish(
+		exchange, //exchange
+		key,      //key
+		true,     //mandatory
+		false,    //immediate
+		newPublishing(envelope.Metadata, payload),
+	); err != nil {
+		return err
+	}
+
+	select {
+	case confirmation, ok := <-c.confirms:
+		if !ok {
+			return fmt.Errorf("channel closed before event %s was confirmed", envelope.Metadata.EventId)
+		}
+
+		if returned, ok := c.returned(); ok {
+			return NewUnroutableError(envelope.Metadata.EventId, returned)
+		}
+
+		if !confirmation.Ack {
+			return NewNackError(envelope.Metadata.EventId, exchange, key)
+		}
+
+		return nil
+	case err := <-c.closes:
+		return fmt.Errorf("channel closed before event %s was confirmed: %v", envelope.Metadata.EventId, err)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *confirmChannel) returned() (amqp.Return, bool) {
+	select {
+	case returned, ok := <-c.returns:
+		return returned, ok
+	default:
+		return amqp.Return{}, false
+	}
+}
+
+func newChannelPool(connector *connector, size int) *channelPool {
+	return &channelPool{
+		connector: connector,
+		size:      size,
+		lock:      &sync.Mutex{},
+		topics:    make(map[string]bool),
+	}
+}
+
+type channelPool struct {
+	connector  *connector
+	size       int
+	lock       *sync.Mutex
+	idle       []*confirmChannel
+	generation int
+	topics     map[string]bool
+}
+
+func (p *channelPool) acquire(ctx context.Context) (*confirmChannel, error) {
+	connection, generation, err := p.connector.Connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	p.renew(generation)
+	for len(p.idle) > 0 {
+		channel := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if !channel.isClosed() {
+			p.lock.Unlock()
+			return channel, nil
+		}
+	}
+	p.lock.Unlock()
+
+	return openConfirmChannel(connection, generation)
+}
+
+func (p *channelPool) release(channel *confirmChannel) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if channel.generation != p.generation || len(p.idle) >= p.size || channel.isClosed() {
+		_ = channel.channel.Close()
+		return
+	}
+
+	p.idle = append(p.idle, channel)
+}
+
+func (p *channelPool) discard(channel *confirmChannel) {
+	_ = channel.channel.Close()
+}
+
+func (p *channelPool) declareTopic(channel *confirmChannel, spec domain.EventDefinition) error {
+	p.lock.Lock()
+	declared := channel.generation == p.generation && p.topics[spec.GetDomain()]
+	p.lock.Unlock()
+
+	if declared {
+		return nil
+	}
+
+	if err := createTopic(channel.channel, spec); err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	if channel.generation == p.generation {
+		p.topics[spec.GetDomain()] = true
+	}
+	p.lock.Unlock()
+
+	return nil
+}
+
+func (p *channelPool) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, channel := range p.idle {
+		_ = channel.channel.Close()
+	}
+	p.idle = nil
+
+	return nil
+}
+
+func (p *channelPool) renew(generation int) {
+	if generation == p.generation {
+		return
+	}
+
+	for _, channel := range p.idle {
+		_ = channel.channel.Close()
+	}
+
+	p.idle = nil
+	p.topics = make(map[string]bool)
+	p.generation = generation
+}
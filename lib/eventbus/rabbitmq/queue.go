@@ -5,12 +5,16 @@ import (
 	"github.com/streadway/amqp"
 )
 
-func createQueue(channel *amqp.Channel, listenerName string, eventHandlers ...domain.EventHandler) (string, error) {
+func createQueue(channel *amqp.Channel, listenerName string, policy RetryPolicy, eventHandlers ...domain.EventHandler) (string, error) {
 	queueName := listenerName
 	if err := declareQueue(channel, queueName); err != nil {
 		return "", err
 	}
 
+	if err := createRetryTopology(channel, queueName, policy); err != nil {
+		return "", err
+	}
+
 	for _, eventHandler := range eventHandlers {
 		if err := createTopic(channel, eventHandler.GetEventDefinition()); err != nil {
 			deleteQueue(channel, queueName)
@@ -26,13 +30,17 @@ func createQueue(channel *amqp.Channel, listenerName string, eventHandlers ...do
 }
 
 func declareQueue(channel *amqp.Channel, queueName string) error {
+	return declareQueueWithArguments(channel, queueName, nil)
+}
+
+func declareQueueWithArguments(channel *amqp.Channel, queueName string, arguments amqp.Table) error {
 	_, err := channel.QueueDeclare(
 		queueName,
 		true,
 		false,
 		false,
 		false,
-		nil,
+		arguments,
 	)
 
 	return err
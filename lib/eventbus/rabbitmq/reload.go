@@ -0,0 +1,83 @@
+package rabbitmq
+
+import (
+	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/frederic-gendebien/pact-poc/lib/config/layered"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/streadway/amqp"
+)
+
+// restartOnPrefetchChange asks a running listener to consume again when its
+// prefetch changes, since the prefetch of a channel only applies to the
+// consumers started after it is set. Concurrency changes need a restart since
+// the worker pool is fixed.
+func (e *EventBus) restartOnPrefetchChange(
+	logger logging.Logger,
+	listenerName string,
+	options domain.ListenerOptions,
+	restarts chan domain.ListenerOptions,
+) func() {
+	return config.OnChange(e.configuration, func(changes []layered.Change) {
+		updated, changed := e.reloadPrefetch(logger, listenerName, options)
+		if !changed {
+			return
+		}
+
+		options = updated
+		select {
+		case <-restarts:
+		default:
+		}
+		restarts <- updated
+	}, listenerPropertyNames(listenerName, listenerPrefetch)...)
+}
+
+// reconfigureOnChange applies retry policy changes to a running listener.
+func (e *EventBus) reconfigureOnChange(logger logging.Logger, channel *amqp.Channel, consumer *consumer, listenerName string) func() {
+	return config.OnChange(e.configuration, func(changes []layered.Change) {
+		e.reconfigureRetryPolicy(logger, channel, consumer, listenerName)
+	}, listenerPropertyNames(listenerName, retryMaxAttempts, retryInitialDelay, retryMaxDelay, retryMultiplier)...)
+}
+
+func (e *EventBus) reloadPrefetch(logger logging.Logger, listenerName string, options domain.ListenerOptions) (domain.ListenerOptions, bool) {
+	reloaded, err := listenerOptionsFor(e.configuration, listenerName)
+	if err != nil {
+		logger.Errorf("keeping prefetch %d: %v", options.PrefetchCount(), err)
+		return options, false
+	}
+
+	updated := options
+	updated.Prefetch = reloaded.Prefetch
+
+	return updated, updated.PrefetchCount() != options.PrefetchCount()
+}
+
+func (e *EventBus) reconfigureRetryPolicy(logger logging.Logger, channel *amqp.Channel, consumer *consumer, listenerName string) {
+	policy, err := retryPolicyFor(e.configuration, listenerName)
+	if err != nil {
+		logger.Errorf("keeping retry policy: %v", err)
+		return
+	}
+
+	if policy == consumer.retryPolicy() {
+		return
+	}
+
+	if err := createRetryTopology(channel, consumer.queueName, policy); err != nil {
+		logger.Errorf("could not declare retry topology, keeping retry policy: %v", err)
+		return
+	}
+
+	consumer.setRetryPolicy(policy)
+	logger.Infof("retry policy changed to %d attempts with delays %v", policy.MaxAttempts, policy.Delays())
+}
+
+func listenerPropertyNames(listenerName string, names ...string) []string {
+	properties := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		properties = append(properties, listenerPropertyName(listenerName, name), propertyName(name))
+	}
+
+	return properties
+}
@@ -0,0 +1,68 @@
+package rabbitmq
+
+import (
+	"github.com/frederic-gendebien/pact-poc/lib/config/layered"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEventBus_RestartOnPrefetchChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfiguration(t, path, "rabbitmq:\n  concurrency: 4\n")
+	file, err := layered.NewFileLayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configuration := layered.NewConfiguration(file)
+	eventBus := &EventBus{
+		configuration: configuration,
+		logger:        logging.NewDiscardLogger(),
+	}
+
+	options, err := listenerOptionsFor(configuration, "projection")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options.PrefetchCount() != 4 {
+		t.Fatalf("expected the prefetch to default to the concurrency, but got: %d", options.PrefetchCount())
+	}
+
+	restarts := make(chan domain.ListenerOptions, 1)
+	unsubscribe := eventBus.restartOnPrefetchChange(eventBus.logger, "projection", options, restarts)
+	defer unsubscribe()
+
+	reload(t, configuration, path, "rabbitmq:\n  concurrency: 4\n  projection:\n    prefetch: 16\n")
+	select {
+	case updated := <-restarts:
+		if updated.PrefetchCount() != 16 || updated.Workers() != options.Workers() {
+			t.Fatalf("expected to consume again with prefetch 16 and %d workers, but got: %+v", options.Workers(), updated)
+		}
+	default:
+		t.Fatal("expected a prefetch change to restart the consume")
+	}
+
+	reload(t, configuration, path, "rabbitmq:\n  concurrency: 4\n  prefetch: 8\n  projection:\n    prefetch: 16\n")
+	reload(t, configuration, path, "rabbitmq:\n  concurrency: 4\n  projection:\n    prefetch: -1\n")
+	select {
+	case updated := <-restarts:
+		t.Fatalf("expected an unchanged or invalid prefetch to keep consuming, but got: %+v", updated)
+	default:
+	}
+}
+
+func reload(t *testing.T, configuration *layered.Configuration, path string, content string) {
+	writeConfiguration(t, path, content)
+	if _, err := configuration.Reload(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeConfiguration(t *testing.T, path string, content string) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,156 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/streadway/amqp"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	retryMaxAttempts  = "RETRY_MAX_ATTEMPTS"
+	retryInitialDelay = "RETRY_INITIAL_DELAY"
+	retryMaxDelay     = "RETRY_MAX_DELAY"
+	retryMultiplier   = "RETRY_MULTIPLIER"
+
+	defaultRetryMaxAttempts  = "3"
+	defaultRetryInitialDelay = "1s"
+	defaultRetryMaxDelay     = "1m"
+	defaultRetryMultiplier   = "2"
+)
+
+type RetryPolicy struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+}
+
+func (p RetryPolicy) CanRetry(attempt int) bool {
+	return attempt < p.MaxAttempts
+}
+
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := time.Duration(float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(attempt-1)))
+	if delay > p.MaxDelay || delay <= 0 {
+		return p.MaxDelay
+	}
+
+	return delay.Truncate(time.Millisecond)
+}
+
+func (p RetryPolicy) Delays() []time.Duration {
+	delays := make([]time.Duration, 0, p.MaxAttempts)
+	seen := make(map[time.Duration]bool)
+	for attempt := 1; p.CanRetry(attempt); attempt++ {
+		delay := p.Delay(attempt)
+		if !seen[delay] {
+			seen[delay] = true
+			delays = append(delays, delay)
+		}
+	}
+
+	return delays
+}
+
+func retryPolicyFor(configuration config.Configuration, listenerName string) (RetryPolicy, error) {
+	maxAttempts, err := config.Int(configuration, listenerPropertyName(listenerName, retryMaxAttempts), propertyName(retryMaxAttempts))
+	if err != nil {
+		return RetryPolicy{}, fmt.Errorf("invalid retry max attempts for listener %s: %v", listenerName, err)
+	}
+	if maxAttempts < 1 {
+		return RetryPolicy{}, fmt.Errorf("invalid retry max attempts for listener %s: %d is lower than 1", listenerName, maxAttempts)
+	}
+
+	initialDelay, err := config.Duration(configuration, listenerPropertyName(listenerName, retryInitialDelay), propertyName(retryInitialDelay))
+	if err != nil {
+		return RetryPolicy{}, fmt.Errorf("invalid retry initial delay for listener %s: %v", listenerName, err)
+	}
+
+	maxDelay, err := config.Duration(configuration, listenerPropertyName(listenerName, retryMaxDelay), propertyName(retryMaxDelay))
+	if err != nil {
+		return RetryPolicy{}, fmt.Errorf("invalid retry max delay for listener %s: %v", listenerName, err)
+	}
+
+	multiplier, err := config.Float(configuration, listenerPropertyName(listenerName, retryMultiplier), propertyName(retryMultiplier))
+	if err != nil {
+		return RetryPolicy{}, fmt.Errorf("invalid retry multiplier for listener %s: %v", listenerName, err)
+	}
+	if multiplier < 1 {
+		return RetryPolicy{}, fmt.Errorf("invalid retry multiplier for listener %s: %g is lower than 1", listenerName, multiplier)
+	}
+
+	return RetryPolicy{
+		MaxAttempts:  maxAttempts,
+		InitialDelay: initialDelay,
+		MaxDelay:     maxDelay,
+		Multiplier:   multiplier,
+	}, nil
+}
+
+func propertyName(name string) string {
+	return "RABBITMQ_" + name
+}
+
+func listenerPropertyName(listenerName string, name string) string {
+	return propertyName(strings.ToUpper(strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, listenerName)) + "_" + name)
+}
+
+func retryExchangeName(queueName string) string {
+	return queueName + ".retry"
+}
+
+func retryQueueName(queueName string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%dms", queueName, delay.Milliseconds())
+}
+
+func retryRoutingKey(delay time.Duration) string {
+	return strconv.FormatInt(delay.Milliseconds(), 10)
+}
+
+func deadLetterExchangeName(queueName string) string {
+	return queueName + ".dead-letter"
+}
+
+func deadLetterQueueName(queueName string) string {
+	return queueName + ".dlq"
+}
+
+func createRetryTopology(channel *amqp.Channel, queueName string, policy RetryPolicy) error {
+	if err := declareExchange(channel, retryExchangeName(queueName), amqp.ExchangeDirect); err != nil {
+		return err
+	}
+
+	for _, delay := range policy.Delays() {
+		retryQueue := retryQueueName(queueName, delay)
+		if err := declareQueueWithArguments(channel, retryQueue, amqp.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		}); err != nil {
+			return err
+		}
+
+		if err := channel.QueueBind(retryQueue, retryRoutingKey(delay), retryExchangeName(queueName), false, nil); err != nil {
+			return err
+		}
+	}
+
+	if err := declareExchange(channel, deadLetterExchangeName(queueName), amqp.ExchangeFanout); err != nil {
+		return err
+	}
+
+	if err := declareQueue(channel, deadLetterQueueName(queueName)); err != nil {
+		return err
+	}
+
+	return channel.QueueBind(deadLetterQueueName(queueName), "", deadLetterExchangeName(queueName), false, nil)
+}
@@ -6,12 +6,16 @@ import (
 )
 
 func createTopic(channel *amqp.Channel, spec domain.EventDefinition) error {
+	return declareExchange(channel, spec.GetDomain(), amqp.ExchangeTopic)
+}
+
+func declareExchange(channel *amqp.Channel, name string, kind string) error {
 	return channel.ExchangeDeclare(
-		spec.GetDomain(), //name
-		"topic",          //kind
-		true,             //durable
-		false,            //auto_delete
-		false,            //internal
+		name,  //name
+		kind,  //kind
+		true,  //durable
+		false, //auto_delete
+		false, //internal
 		false,
 		nil,
 	)
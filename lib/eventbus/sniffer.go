@@ -3,20 +3,26 @@ package eventbus
 import (
 	"context"
 	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
-	"log"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
 	"sync"
 )
 
-func NewEventSniffer(eventBus EventBus) *EventSniffer {
+const (
+	snifferListenerName = "event-sniffer"
+)
+
+func NewEventSniffer(eventBus EventBus, logger logging.Logger) *EventSniffer {
 	return &EventSniffer{
 		lock:     &sync.RWMutex{},
 		eventBus: eventBus,
+		logger:   logger.With(logging.Listener, snifferListenerName),
 	}
 }
 
 type EventSniffer struct {
 	lock     *sync.RWMutex
 	eventBus EventBus
+	logger   logging.Logger
 	events   []interface{}
 }
 
@@ -27,7 +33,7 @@ func (e *EventSniffer) Listen(eventDefinitions ...domain.EventDefinition) error
 	}
 
 	return e.eventBus.Listen(context.Background(),
-		"event-sniffer",
+		snifferListenerName,
 		eventHandlers...,
 	)
 }
@@ -78,12 +84,15 @@ func (e EventListener) GetEventDefinition() domain.EventDefinition {
 	return e.eventDefinition
 }
 
-func (e EventListener) ProcessEvent(event interface{}) error {
-	e.eventSniffer.AddEvent(event)
+func (e EventListener) ProcessEvent(envelope domain.Envelope) error {
+	e.eventSniffer.AddEvent(envelope.Event)
 
 	return nil
 }
 
-func (e EventListener) HandleError(event interface{}, err error) {
-	log.Printf("could not process event: %v: %v", event, err)
+func (e EventListener) HandleError(envelope domain.Envelope, err error) {
+	e.eventSniffer.logger.
+		With(logging.EventId, envelope.Metadata.EventId).
+		With(logging.EventName, envelope.Metadata.Name).
+		Errorf("could not process event: %v", err)
 }
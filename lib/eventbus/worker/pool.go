@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"hash/fnv"
+	"sync"
+)
+
+var (
+	ErrClosed = errors.New("worker pool is closed")
+)
+
+func NewPool(options domain.ListenerOptions) *Pool {
+	workers := options.Workers()
+	queues := 1
+	if options.OrderedByEntity {
+		queues = workers
+	}
+
+	pool := &Pool{
+		ordered: options.OrderedByEntity,
+		queues:  make([]chan func(), queues),
+		wait:    &sync.WaitGroup{},
+		lock:    &sync.RWMutex{},
+		closing: make(chan struct{}),
+	}
+
+	for i := range pool.queues {
+		pool.queues[i] = make(chan func(), options.PrefetchCount())
+	}
+
+	for i := 0; i < workers; i++ {
+		pool.wait.Add(1)
+		go pool.work(pool.queues[i%queues])
+	}
+
+	return pool
+}
+
+type Pool struct {
+	ordered bool
+	queues  []chan func()
+	wait    *sync.WaitGroup
+	lock    *sync.RWMutex
+	closing chan struct{}
+	once    sync.Once
+}
+
+// Submit queues the task, waiting for room in its queue until the context is
+// done or the pool is closed.
+func (p *Pool) Submit(ctx context.Context, entityId string, task func()) error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	select {
+	case <-p.closing:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case p.queues[p.shard(entityId)] <- task:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.closing:
+		return ErrClosed
+	}
+}
+
+func (p *Pool) Pending() int {
+	pending := 0
+	for _, queue := range p.queues {
+		pending += len(queue)
+	}
+
+	return pending
+}
+
+func (p *Pool) Close() {
+	p.once.Do(func() {
+		close(p.closing)
+
+		p.lock.Lock()
+		defer p.lock.Unlock()
+
+		for _, queue := range p.queues {
+			close(queue)
+		}
+	})
+
+	p.wait.Wait()
+}
+
+func (p *Pool) shard(entityId string) int {
+	if !p.ordered {
+		return 0
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(entityId))
+
+	return int(hash.Sum32() % uint32(len(p.queues)))
+}
+
+func (p *Pool) work(tasks <-chan func()) {
+	defer p.wait.Done()
+
+	for task := range tasks {
+		task()
+	}
+}
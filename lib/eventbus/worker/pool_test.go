@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPool_OrderedByEntity(t *testing.T) {
+	pool := NewPool(domain.ListenerOptions{
+		Concurrency:     4,
+		Prefetch:        2,
+		OrderedByEntity: true,
+	})
+
+	lock := &sync.Mutex{}
+	processed := make(map[string][]int)
+	for sequence := 0; sequence < 100; sequence++ {
+		for entity := 0; entity < 10; entity++ {
+			entityId, sequence := fmt.Sprintf("user%d", entity), sequence
+			pool.Submit(context.Background(), entityId, func() {
+				lock.Lock()
+				defer lock.Unlock()
+				processed[entityId] = append(processed[entityId], sequence)
+			})
+		}
+	}
+
+	pool.Close()
+
+	for entityId, sequences := range processed {
+		if len(sequences) != 100 {
+			t.Fatalf("expected 100 events for %s, but got %d", entityId, len(sequences))
+		}
+
+		for i, sequence := range sequences {
+			if sequence != i {
+				t.Fatalf("events of %s were processed out of order: %v", entityId, sequences)
+			}
+		}
+	}
+}
+
+func TestPool_Unordered(t *testing.T) {
+	pool := NewPool(domain.ListenerOptions{Concurrency: 3})
+
+	lock := &sync.Mutex{}
+	counter := 0
+	for i := 0; i < 50; i++ {
+		pool.Submit(context.Background(), "", func() {
+			lock.Lock()
+			defer lock.Unlock()
+			counter++
+		})
+	}
+
+	pool.Close()
+
+	if counter != 50 {
+		t.Fatalf("expected 50 processed tasks, but got %d", counter)
+	}
+}
+
+func TestPool_SubmitStopsWaiting(t *testing.T) {
+	pool := NewPool(domain.ListenerOptions{Concurrency: 1, Prefetch: 1})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	if err := pool.Submit(context.Background(), "", func() {
+		close(started)
+		<-release
+	}); err != nil {
+		t.Fatalf("could not submit task: %v", err)
+	}
+	<-started
+
+	if err := pool.Submit(context.Background(), "", func() {}); err != nil {
+		t.Fatalf("could not queue task: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := pool.Submit(ctx, "", func() {}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected submit to a full queue to stop with the context, but got: %v", err)
+	}
+
+	close(release)
+	pool.Close()
+
+	if err := pool.Submit(context.Background(), "", func() {}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected submit to a closed pool to fail, but got: %v", err)
+	}
+}
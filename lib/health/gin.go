@@ -0,0 +1,25 @@
+package health
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+func AddGinHandlers(engine *gin.Engine, checks *Health) {
+	group := engine.Group("/health")
+	group.GET("live", GinHandler(checks.Live))
+	group.GET("ready", GinHandler(checks.Ready))
+}
+
+func GinHandler(report func(ctx context.Context) Report) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result := report(ctx)
+		status := http.StatusOK
+		if !result.IsUp() {
+			status = http.StatusServiceUnavailable
+		}
+
+		ctx.JSON(status, result)
+	}
+}
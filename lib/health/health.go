@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"sync"
+	"time"
+)
+
+const (
+	CheckTimeout        = "HEALTH_CHECK_TIMEOUT"
+	defaultCheckTimeout = "2s"
+)
+
+func init() {
+	config.Register(config.Property{
+		Name:        CheckTimeout,
+		Type:        config.TypeDuration,
+		Default:     defaultCheckTimeout,
+		Description: "timeout of each readiness check",
+	})
+}
+
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+type Checker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+type CheckerFunc func(ctx context.Context) error
+
+func (c CheckerFunc) CheckHealth(ctx context.Context) error {
+	return c(ctx)
+}
+
+type Check struct {
+	Status   Status `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type Report struct {
+	Status Status           `json:"status"`
+	Checks map[string]Check `json:"checks"`
+}
+
+func (r Report) IsUp() bool {
+	return r.Status == StatusUp
+}
+
+func NewHealth(configuration config.Configuration, logger logging.Logger) *Health {
+	timeout, err := config.Duration(configuration, CheckTimeout)
+	if err != nil {
+		logger.Fatalf("invalid health check timeout: %v", err)
+	}
+
+	return &Health{
+		timeout:  timeout,
+		checkers: make(map[string]Checker),
+	}
+}
+
+type Health struct {
+	timeout  time.Duration
+	checkers map[string]Checker
+}
+
+func (h *Health) Add(name string, checker Checker) *Health {
+	h.checkers[name] = checker
+
+	return h
+}
+
+func (h *Health) Live(ctx context.Context) Report {
+	return Report{
+		Status: StatusUp,
+		Checks: map[string]Check{},
+	}
+}
+
+func (h *Health) Ready(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	lock := &sync.Mutex{}
+	wait := &sync.WaitGroup{}
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]Check, len(h.checkers)),
+	}
+
+	for name, checker := range h.checkers {
+		wait.Add(1)
+		go func(name string, checker Checker) {
+			defer wait.Done()
+			check := run(ctx, checker)
+
+			lock.Lock()
+			defer lock.Unlock()
+			report.Checks[name] = check
+			if check.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(name, checker)
+	}
+	wait.Wait()
+
+	return report
+}
+
+func run(ctx context.Context, checker Checker) Check {
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() {
+		result <- checker.CheckHealth(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = fmt.Errorf("health check timed out: %v", ctx.Err())
+	}
+
+	check := Check{
+		Status:   StatusUp,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		check.Status = StatusDown
+		check.Error = err.Error()
+	}
+
+	return check
+}
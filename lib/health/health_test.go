@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/frederic-gendebien/pact-poc/lib/config/environment"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fixedLag int
+
+func (f fixedLag) Lag(ctx context.Context, listenerName string) (int, error) {
+	return int(f), nil
+}
+
+func TestHealth_Ready(t *testing.T) {
+	checks := NewHealth(environment.NewConfiguration(), logging.NewDiscardLogger()).
+		Add("database", CheckerFunc(func(ctx context.Context) error {
+			return nil
+		})).
+		Add("broker", CheckerFunc(func(ctx context.Context) error {
+			return errors.New("disconnected")
+		})).
+		Add("lag", NewLagChecker(fixedLag(5), "listener", 10))
+
+	report := checks.Ready(context.Background())
+	if report.IsUp() {
+		t.Fatalf("expected report to be down, but got: %+v", report)
+	}
+
+	if check := report.Checks["broker"]; check.Status != StatusDown || check.Error != "disconnected" {
+		t.Fatalf("expected broker to be down, but got: %+v", check)
+	}
+
+	for _, name := range []string{"database", "lag"} {
+		if check := report.Checks[name]; check.Status != StatusUp {
+			t.Fatalf("expected %s to be up, but got: %+v", name, check)
+		}
+	}
+
+	if lagging := NewLagChecker(fixedLag(11), "listener", 10).CheckHealth(context.Background()); lagging == nil {
+		t.Fatalf("expected lagging listener to be unhealthy")
+	}
+}
+
+func TestAddGinHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	AddGinHandlers(engine, NewHealth(environment.NewConfiguration(), logging.NewDiscardLogger()).
+		Add("broker", CheckerFunc(func(ctx context.Context) error {
+			return errors.New("disconnected")
+		})))
+
+	for path, expected := range map[string]int{
+		"/health/live":  http.StatusOK,
+		"/health/ready": http.StatusServiceUnavailable,
+	} {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if recorder.Code != expected {
+			t.Fatalf("expected status %d for %s, but got: %d", expected, path, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+type LagReporter interface {
+	Lag(ctx context.Context, listenerName string) (int, error)
+}
+
+func NewLagChecker(reporter LagReporter, listenerName string, maxLag int) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		lag, err := reporter.Lag(ctx, listenerName)
+		if err != nil {
+			return fmt.Errorf("could not measure lag of listener %s: %v", listenerName, err)
+		}
+
+		if lag > maxLag {
+			return fmt.Errorf("listener %s is lagging behind with %d pending events (max %d)", listenerName, lag, maxLag)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,49 @@
+package lifecycle
+
+import (
+	"context"
+	"io"
+)
+
+type Component interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+func NewComponent(start func(ctx context.Context) error, stop func(ctx context.Context) error) Component {
+	return component{
+		start: start,
+		stop:  stop,
+	}
+}
+
+func Closer(closer io.Closer) Component {
+	return NewComponent(nil, func(ctx context.Context) error {
+		return closer.Close()
+	})
+}
+
+func Routine(run func(ctx context.Context) error) Component {
+	return NewComponent(run, nil)
+}
+
+type component struct {
+	start func(ctx context.Context) error
+	stop  func(ctx context.Context) error
+}
+
+func (c component) Start(ctx context.Context) error {
+	if c.start == nil {
+		return nil
+	}
+
+	return c.start(ctx)
+}
+
+func (c component) Stop(ctx context.Context) error {
+	if c.stop == nil {
+		return nil
+	}
+
+	return c.stop(ctx)
+}
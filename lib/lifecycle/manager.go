@@ -0,0 +1,135 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	ShutdownTimeout        = "LIFECYCLE_SHUTDOWN_TIMEOUT"
+	defaultShutdownTimeout = "30s"
+)
+
+func init() {
+	config.Register(config.Property{
+		Name:        ShutdownTimeout,
+		Type:        config.TypeDuration,
+		Default:     defaultShutdownTimeout,
+		Description: "maximum time given to the components to shut down",
+	})
+}
+
+func NewManager(configuration config.Configuration, logger logging.Logger) *Manager {
+	timeout, err := config.Duration(configuration, ShutdownTimeout)
+	if err != nil {
+		logger.Fatalf("invalid shutdown timeout: %v", err)
+	}
+
+	return &Manager{
+		logger:  logger,
+		timeout: timeout,
+	}
+}
+
+type Manager struct {
+	logger     logging.Logger
+	timeout    time.Duration
+	components []*managedComponent
+}
+
+func (m *Manager) Add(name string, component Component) *Manager {
+	m.components = append(m.components, &managedComponent{
+		name:      name,
+		component: component,
+	})
+
+	return m
+}
+
+func (m *Manager) Run() error {
+	signals, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	failures := make(chan error, len(m.components))
+	for _, managed := range m.components {
+		managed.start(failures)
+	}
+
+	var failure error
+	select {
+	case <-signals.Done():
+		m.logger.Infof("received shutdown signal")
+	case failure = <-failures:
+		m.logger.Errorf("shutting down after failure: %v", failure)
+	}
+
+	if err := m.shutdown(); failure == nil {
+		failure = err
+	}
+
+	return failure
+}
+
+func (m *Manager) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	problems := make([]string, 0)
+	for i := len(m.components) - 1; i >= 0; i-- {
+		m.logger.Infof("shutting down %s", m.components[i].name)
+		if err := m.components[i].stop(ctx); err != nil {
+			m.logger.Errorf("could not stop %s: %v", m.components[i].name, err)
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("shutdown failed: %s", strings.Join(problems, ", "))
+	}
+
+	m.logger.Infof("shutdown complete")
+
+	return nil
+}
+
+type managedComponent struct {
+	name      string
+	component Component
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func (m *managedComponent) start(failures chan<- error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel, m.done = cancel, make(chan struct{})
+
+	go func() {
+		defer close(m.done)
+
+		if err := m.component.Start(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			failures <- fmt.Errorf("%s failed: %w", m.name, err)
+		}
+	}()
+}
+
+func (m *managedComponent) stop(ctx context.Context) error {
+	m.cancel()
+
+	if err := m.component.Stop(ctx); err != nil {
+		return fmt.Errorf("%s: %w", m.name, err)
+	}
+
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s did not stop in time: %w", m.name, ctx.Err())
+	}
+}
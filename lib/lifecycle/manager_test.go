@@ -0,0 +1,43 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"github.com/frederic-gendebien/pact-poc/lib/config/environment"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestManager_StopsInReverseOrderAfterFailure(t *testing.T) {
+	lock := &sync.Mutex{}
+	stopped := make([]string, 0, 3)
+	stop := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			lock.Lock()
+			defer lock.Unlock()
+			stopped = append(stopped, name)
+			return nil
+		}
+	}
+
+	failure := errors.New("boom")
+	manager := NewManager(environment.NewConfiguration(), logging.NewDiscardLogger()).
+		Add("first", NewComponent(nil, stop("first"))).
+		Add("second", NewComponent(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, stop("second"))).
+		Add("third", NewComponent(func(ctx context.Context) error {
+			return failure
+		}, stop("third")))
+
+	if err := manager.Run(); !errors.Is(err, failure) {
+		t.Fatalf("expected failure %v, but got: %v", failure, err)
+	}
+
+	if expected := []string{"third", "second", "first"}; !reflect.DeepEqual(stopped, expected) {
+		t.Fatalf("expected: %v, but got %v", expected, stopped)
+	}
+}
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"context"
+)
+
+const (
+	Service       = "service"
+	RequestId     = "request_id"
+	UserId        = "user_id"
+	EventId       = "event_id"
+	EventName     = "event_name"
+	CorrelationId = "correlation_id"
+	Listener      = "listener"
+	TraceId       = "trace_id"
+	SpanId        = "span_id"
+)
+
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+type fieldsKey struct{}
+
+func WithField(ctx context.Context, key string, value interface{}) context.Context {
+	existing := FieldsFrom(ctx)
+	fields := make([]Field, 0, len(existing)+1)
+	for _, field := range existing {
+		if field.Key != key {
+			fields = append(fields, field)
+		}
+	}
+
+	return context.WithValue(ctx, fieldsKey{}, append(fields, Field{Key: key, Value: value}))
+}
+
+func FieldsFrom(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+	return fields
+}
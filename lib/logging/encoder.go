@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Entry struct {
+	Time    time.Time
+	Level   Severity
+	Message string
+	Fields  []Field
+}
+
+type Encoder interface {
+	Encode(entry Entry) []byte
+}
+
+type textEncoder struct{}
+
+func (t textEncoder) Encode(entry Entry) []byte {
+	buffer := &bytes.Buffer{}
+	buffer.WriteString(entry.Time.Format(time.RFC3339Nano))
+	buffer.WriteString(" ")
+	buffer.WriteString(strings.ToUpper(entry.Level.String()))
+	buffer.WriteString(" ")
+	buffer.WriteString(entry.Message)
+	for _, field := range entry.Fields {
+		buffer.WriteString(" ")
+		buffer.WriteString(field.Key)
+		buffer.WriteString("=")
+		buffer.WriteString(quoteIfNeeded(fmt.Sprint(field.Value)))
+	}
+	buffer.WriteString("\n")
+
+	return buffer.Bytes()
+}
+
+func quoteIfNeeded(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
+
+type jsonEncoder struct{}
+
+func (j jsonEncoder) Encode(entry Entry) []byte {
+	buffer := &bytes.Buffer{}
+	buffer.WriteString("{")
+	writeJSONField(buffer, "time", entry.Time.Format(time.RFC3339Nano))
+	buffer.WriteString(",")
+	writeJSONField(buffer, "level", entry.Level.String())
+	buffer.WriteString(",")
+	writeJSONField(buffer, "msg", entry.Message)
+	for _, field := range entry.Fields {
+		buffer.WriteString(",")
+		writeJSONField(buffer, field.Key, jsonValue(field.Value))
+	}
+	buffer.WriteString("}\n")
+
+	return buffer.Bytes()
+}
+
+func writeJSONField(buffer *bytes.Buffer, key string, value interface{}) {
+	encodedKey, _ := json.Marshal(key)
+	encodedValue, err := json.Marshal(value)
+	if err != nil {
+		encodedValue, _ = json.Marshal(fmt.Sprint(value))
+	}
+
+	buffer.Write(encodedKey)
+	buffer.WriteString(":")
+	buffer.Write(encodedValue)
+}
+
+func jsonValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case error:
+		return typed.Error()
+	case fmt.Stringer:
+		return typed.String()
+	default:
+		return value
+	}
+}
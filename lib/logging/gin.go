@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+const (
+	RequestIdHeader = "X-Request-Id"
+)
+
+func AddGinMiddleware(engine *gin.Engine, logger Logger) {
+	engine.Use(GinMiddleware(logger), gin.Recovery())
+}
+
+func GinMiddleware(logger Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		requestId := ctx.GetHeader(RequestIdHeader)
+		if requestId == "" {
+			requestId = newRequestId()
+		}
+		ctx.Header(RequestIdHeader, requestId)
+
+		request := WithField(ctx.Request.Context(), RequestId, requestId)
+		if userId := ctx.Param("user_id"); userId != "" {
+			request = WithField(request, UserId, userId)
+		}
+		ctx.Request = ctx.Request.WithContext(request)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		access := logger.WithContext(ctx.Request.Context()).
+			With("method", ctx.Request.Method).
+			With("path", ctx.Request.URL.Path).
+			With("status", status).
+			With("duration", time.Since(start).String())
+		if len(ctx.Errors) > 0 {
+			access = access.With("errors", ctx.Errors.String())
+		}
+
+		if status >= http.StatusInternalServerError {
+			access.Errorf("%s %s", ctx.Request.Method, route(ctx))
+		} else {
+			access.Infof("%s %s", ctx.Request.Method, route(ctx))
+		}
+	}
+}
+
+func route(ctx *gin.Context) string {
+	if route := ctx.FullPath(); route != "" {
+		return route
+	}
+
+	return ctx.Request.URL.Path
+}
+
+func newRequestId() string {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(bytes)
+}
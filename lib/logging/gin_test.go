@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAddGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	buffer := &bytes.Buffer{}
+	engine := gin.New()
+	AddGinMiddleware(engine, NewWriterLogger(buffer, SeverityInfo, jsonEncoder{}))
+	engine.GET("/users/:user_id", func(ctx *gin.Context) {
+		panic("broken handler")
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/users/user1", nil)
+	request.Header.Set(RequestIdHeader, "request-1")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusInternalServerError || recorder.Header().Get(RequestIdHeader) != "request-1" {
+		t.Fatalf("expected the panic to be recovered with the request id, but got: %d, %v", recorder.Code, recorder.Header())
+	}
+
+	entry := make(map[string]interface{})
+	if err := json.Unmarshal(buffer.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one access log entry, but got: %s", buffer.String())
+	}
+
+	expected := map[string]interface{}{
+		"level":      "error",
+		"msg":        "GET /users/:user_id",
+		"request_id": "request-1",
+		"user_id":    "user1",
+		"status":     float64(http.StatusInternalServerError),
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Fatalf("expected %s to be %v, but got: %v", key, value, entry[key])
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Severity int
+
+const (
+	SeverityDebug Severity = iota
+	SeverityInfo
+	SeverityWarn
+	SeverityError
+	SeverityFatal
+)
+
+func ParseLevel(level string) (Severity, error) {
+	switch strings.ToLower(level) {
+	case LevelDebug:
+		return SeverityDebug, nil
+	case LevelInfo:
+		return SeverityInfo, nil
+	case LevelWarn:
+		return SeverityWarn, nil
+	case LevelError:
+		return SeverityError, nil
+	default:
+		return SeverityInfo, fmt.Errorf("unknown log level: %s", level)
+	}
+}
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityDebug:
+		return "debug"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarn:
+		return "warn"
+	case SeverityError:
+		return "error"
+	case SeverityFatal:
+		return "fatal"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,194 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/frederic-gendebien/pact-poc/lib/config/layered"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	Format       = "LOG_FORMAT"
+	FormatText   = "text"
+	FormatJSON   = "json"
+	Level        = "LOG_LEVEL"
+	LevelDebug   = "debug"
+	LevelInfo    = "info"
+	LevelWarn    = "warn"
+	LevelError   = "error"
+	defaultLevel = LevelInfo
+)
+
+func init() {
+	config.Register(
+		config.Property{
+			Name:        Format,
+			Default:     FormatText,
+			Allowed:     []string{FormatText, FormatJSON},
+			Description: "log output format",
+		},
+		config.Property{
+			Name:        Level,
+			Default:     defaultLevel,
+			Allowed:     []string{LevelDebug, LevelInfo, LevelWarn, LevelError},
+			Description: "minimum level of the logs to write",
+		},
+	)
+}
+
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+	With(key string, value interface{}) Logger
+	WithContext(ctx context.Context) Logger
+}
+
+func NewLogger(configuration config.Configuration) Logger {
+	level, err := ParseLevel(config.String(configuration, Level))
+	if err != nil {
+		log.Fatalf("invalid log level: %v", err)
+	}
+
+	var logger *WriterLogger
+	format := config.String(configuration, Format)
+	switch format {
+	case FormatText:
+		logger = NewWriterLogger(os.Stderr, level, textEncoder{})
+	case FormatJSON:
+		logger = NewWriterLogger(os.Stderr, level, jsonEncoder{})
+	default:
+		log.Fatalf("unknown log format: %s", format)
+	}
+
+	config.OnChange(configuration, func(changes []layered.Change) {
+		level, err := ParseLevel(changes[0].Current)
+		if err != nil {
+			logger.Errorf("keeping log level %s: %v", logger.Level(), err)
+			return
+		}
+
+		logger.SetLevel(level)
+	}, Level)
+
+	return logger
+}
+
+func NewDiscardLogger() Logger {
+	return NewWriterLogger(io.Discard, SeverityError, textEncoder{})
+}
+
+func NewWriterLogger(writer io.Writer, level Severity, encoder Encoder) *WriterLogger {
+	threshold := int32(level)
+
+	return &WriterLogger{
+		output: &output{
+			writer: writer,
+			lock:   &sync.Mutex{},
+		},
+		level:   &threshold,
+		encoder: encoder,
+	}
+}
+
+type output struct {
+	writer io.Writer
+	lock   *sync.Mutex
+}
+
+// WriterLogger shares its level with the loggers derived from it, so changing
+// the level applies to all of them.
+type WriterLogger struct {
+	output  *output
+	level   *int32
+	encoder Encoder
+	fields  []Field
+}
+
+func (w *WriterLogger) Level() Severity {
+	return Severity(atomic.LoadInt32(w.level))
+}
+
+func (w *WriterLogger) SetLevel(level Severity) {
+	atomic.StoreInt32(w.level, int32(level))
+}
+
+func (w *WriterLogger) Debugf(format string, args ...interface{}) {
+	w.write(SeverityDebug, format, args)
+}
+
+func (w *WriterLogger) Infof(format string, args ...interface{}) {
+	w.write(SeverityInfo, format, args)
+}
+
+func (w *WriterLogger) Warnf(format string, args ...interface{}) {
+	w.write(SeverityWarn, format, args)
+}
+
+func (w *WriterLogger) Errorf(format string, args ...interface{}) {
+	w.write(SeverityError, format, args)
+}
+
+func (w *WriterLogger) Fatalf(format string, args ...interface{}) {
+	w.write(SeverityFatal, format, args)
+	os.Exit(1)
+}
+
+func (w *WriterLogger) With(key string, value interface{}) Logger {
+	fields := make([]Field, 0, len(w.fields)+1)
+	for _, field := range w.fields {
+		if field.Key != key {
+			fields = append(fields, field)
+		}
+	}
+
+	return &WriterLogger{
+		output:  w.output,
+		level:   w.level,
+		encoder: w.encoder,
+		fields:  append(fields, Field{Key: key, Value: value}),
+	}
+}
+
+func (w *WriterLogger) WithContext(ctx context.Context) Logger {
+	var logger Logger = w
+	for _, field := range FieldsFrom(ctx) {
+		logger = logger.With(field.Key, field.Value)
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		logger = logger.
+			With(TraceId, spanContext.TraceID().String()).
+			With(SpanId, spanContext.SpanID().String())
+	}
+
+	return logger
+}
+
+func (w *WriterLogger) write(severity Severity, format string, args []interface{}) {
+	if severity < w.Level() {
+		return
+	}
+
+	line := w.encoder.Encode(Entry{
+		Time:    time.Now().UTC(),
+		Level:   severity,
+		Message: strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"),
+		Fields:  w.fields,
+	})
+
+	w.output.lock.Lock()
+	defer w.output.lock.Unlock()
+
+	_, _ = w.output.writer.Write(line)
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriterLogger_JSON(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := NewWriterLogger(buffer, SeverityInfo, jsonEncoder{}).With(Service, "server")
+
+	ctx := WithField(context.Background(), RequestId, "request-1")
+	ctx = WithField(ctx, UserId, "user-1")
+	logger.Debugf("hidden")
+	logger.WithContext(ctx).With(UserId, "user-2").Warnf("user %s", "updated")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, but got: %v", lines)
+	}
+
+	entry := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"level":      "warn",
+		"msg":        "user updated",
+		"service":    "server",
+		"request_id": "request-1",
+		"user_id":    "user-2",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Fatalf("expected %s to be %v, but got: %v", key, value, entry[key])
+		}
+	}
+}
+
+func TestWriterLogger_Text(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := NewWriterLogger(buffer, SeverityDebug, textEncoder{})
+
+	logger.With(Listener, "projection").With(EventId, "event 1").Debugf("processed event")
+
+	line := buffer.String()
+	if !strings.Contains(line, ` DEBUG processed event listener=projection event_id="event 1"`) {
+		t.Fatalf("unexpected line: %s", line)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if level, err := ParseLevel("WARN"); err != nil || level != SeverityWarn {
+		t.Fatalf("expected warn level, but got: %v %v", level, err)
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatal("expected an error for an unknown level")
+	}
+}
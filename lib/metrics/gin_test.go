@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAddGinHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	AddGinHandlers(engine)
+	engine.GET("/users/:user_id", func(ctx *gin.Context) {
+		ctx.Status(http.StatusNoContent)
+	})
+
+	matched := httpRequestDuration.WithLabelValues(http.MethodGet, "/users/:user_id", "204")
+	unmatched := httpRequestDuration.WithLabelValues(http.MethodGet, unmatchedRoute, "404")
+	matchedCount, unmatchedCount := sampleCount(t, matched), sampleCount(t, unmatched)
+
+	for _, path := range []string{"/users/user1", "/users/user2", "/unknown"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if count := sampleCount(t, matched) - matchedCount; count != 2 {
+		t.Fatalf("expected 2 requests labelled with their route, but got: %d", count)
+	}
+
+	if count := sampleCount(t, unmatched) - unmatchedCount; count != 1 {
+		t.Fatalf("expected 1 unmatched request, but got: %d", count)
+	}
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected metrics to be exposed, but got: %d", recorder.Code)
+	}
+}
+
+func sampleCount(t *testing.T, observer prometheus.Observer) uint64 {
+	metric := &dto.Metric{}
+	if err := observer.(prometheus.Metric).Write(metric); err != nil {
+		t.Fatalf("could not read metric: %v", err)
+	}
+
+	return metric.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+var (
+	repositoryOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "repository_operation_duration_seconds",
+		Help:    "Duration of repository operations.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"repository", "operation", "outcome"})
+)
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeFailure
+	}
+
+	return OutcomeSuccess
+}
+
+func ObserveRepositoryOperation(repository string, operation string, start time.Time, err error) {
+	repositoryOperationDuration.
+		WithLabelValues(repository, operation, Outcome(err)).
+		Observe(time.Since(start).Seconds())
+}
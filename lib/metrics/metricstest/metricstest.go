@@ -0,0 +1,42 @@
+// Package metricstest reads the metrics of the default registry in tests.
+package metricstest
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"testing"
+)
+
+// SampleCount returns the number of observations of the histogram with the
+// given name and labels, zero when it was never observed.
+func SampleCount(t testing.TB, name string, labels map[string]string) uint64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("could not gather metrics: %v", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			if hasLabels(metric, labels) {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+
+	return 0
+}
+
+func hasLabels(metric *dto.Metric, labels map[string]string) bool {
+	matching := 0
+	for _, label := range metric.GetLabel() {
+		if value, present := labels[label.GetName()]; present && value == label.GetValue() {
+			matching++
+		}
+	}
+
+	return matching == len(labels)
+}
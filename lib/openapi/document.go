@@ -0,0 +1,173 @@
+package openapi
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const (
+	Version         = "3.0.3"
+	ContentTypeJSON = "application/json"
+	ContentTypeText = "text/plain"
+)
+
+var (
+	ginParameter = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+)
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+	types      map[reflect.Type]string
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type PathItem map[string]*Operation
+
+func (p PathItem) operation(method string) *Operation {
+	return p[strings.ToLower(method)]
+}
+
+type Operation struct {
+	OperationId string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+func NewDocument(title string, version string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:   title,
+			Version: version,
+		},
+		Paths: make(map[string]*PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+		},
+		types: make(map[reflect.Type]string),
+	}
+}
+
+// Add registers the operation of a gin route. The gin path parameters are
+// converted and declared as mandatory path parameters.
+func (d *Document) Add(method string, path string, operation Operation) *Document {
+	for _, match := range ginParameter.FindAllStringSubmatch(path, -1) {
+		operation.Parameters = append([]Parameter{{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: TypeString},
+		}}, operation.Parameters...)
+	}
+
+	openAPIPath := ginParameter.ReplaceAllString(path, "{$1}")
+	item, found := d.Paths[openAPIPath]
+	if !found {
+		item = &PathItem{}
+		d.Paths[openAPIPath] = item
+	}
+	(*item)[strings.ToLower(method)] = &operation
+
+	return d
+}
+
+// Has tells whether the gin route is documented.
+func (d *Document) Has(method string, path string) bool {
+	item, found := d.Paths[ginParameter.ReplaceAllString(path, "{$1}")]
+	if !found {
+		return false
+	}
+
+	return item.operation(method) != nil
+}
+
+// Schema returns the schema of the value's type, registering the structs it
+// is made of as components.
+func (d *Document) Schema(value interface{}) *Schema {
+	return d.schemaOf(reflect.TypeOf(value))
+}
+
+func (d *Document) JSON(value interface{}) map[string]MediaType {
+	return map[string]MediaType{
+		ContentTypeJSON: {Schema: d.Schema(value)},
+	}
+}
+
+func (d *Document) JSONBody(value interface{}) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  d.JSON(value),
+	}
+}
+
+func (d *Document) JSONResponse(description string, value interface{}) Response {
+	return Response{
+		Description: description,
+		Content:     d.JSON(value),
+	}
+}
+
+func EmptyResponse(description string) Response {
+	return Response{
+		Description: description,
+	}
+}
+
+func Text() map[string]MediaType {
+	return map[string]MediaType{
+		ContentTypeText: {Schema: &Schema{Type: TypeString}},
+	}
+}
+
+func QueryParameter(name string, schema *Schema, required bool, description string) Parameter {
+	return Parameter{
+		Name:        name,
+		In:          "query",
+		Required:    required,
+		Description: description,
+		Schema:      schema,
+	}
+}
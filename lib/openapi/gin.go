@@ -0,0 +1,24 @@
+package openapi
+
+import (
+	"github.com/gin-gonic/gin"
+	gohttp "net/http"
+)
+
+func Handler(document *Document) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(gohttp.StatusOK, document)
+	}
+}
+
+// Undocumented lists the routes of the engine missing from the document.
+func Undocumented(engine *gin.Engine, document *Document) []gin.RouteInfo {
+	undocumented := make([]gin.RouteInfo, 0)
+	for _, route := range engine.Routes() {
+		if !document.Has(route.Method, route.Path) {
+			undocumented = append(undocumented, route)
+		}
+	}
+
+	return undocumented
+}
@@ -0,0 +1,83 @@
+package openapi
+
+import (
+	"github.com/gin-gonic/gin"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type details struct {
+	Name string `json:"name"`
+}
+
+type user struct {
+	Id        string         `json:"id"`
+	Details   details        `json:"details"`
+	Tags      []string       `json:"tags,omitempty"`
+	Labels    map[string]int `json:"labels"`
+	CreatedAt time.Time      `json:"created_at"`
+	DeletedAt *time.Time     `json:"deleted_at,omitempty"`
+	Event     interface{}    `json:"event"`
+	Ignored   string         `json:"-"`
+	internal  string
+	Friends   []user            `json:"friends,omitempty"`
+	Extra     map[string]string `json:"extra,omitempty"`
+}
+
+func TestDocument_Schema(t *testing.T) {
+	document := NewDocument("test", "1.0.0")
+
+	if ref := document.Schema([]user{}); ref.Type != TypeArray || ref.Items.Ref != "#/components/schemas/user" {
+		t.Fatalf("expected an array of user references, but got: %+v", ref)
+	}
+
+	schema := document.Components.Schemas["user"]
+	if schema == nil {
+		t.Fatalf("expected user to be registered, but got: %v", document.Components.Schemas)
+	}
+
+	if expected := []string{"id", "details", "labels", "created_at", "event"}; !reflect.DeepEqual(schema.Required, expected) {
+		t.Fatalf("expected required properties %v, but got: %v", expected, schema.Required)
+	}
+
+	if _, found := schema.Properties["Ignored"]; found || len(schema.Properties) != 9 {
+		t.Fatalf("expected 9 exported properties, but got: %v", schema.Properties)
+	}
+
+	if created := schema.Properties["created_at"]; created.Type != TypeString || created.Format != "date-time" {
+		t.Fatalf("expected created_at to be a date-time, but got: %+v", created)
+	}
+
+	if labels := schema.Properties["labels"]; labels.Type != TypeObject || labels.AdditionalProperties.Type != TypeInteger {
+		t.Fatalf("expected labels to be a map of integers, but got: %+v", labels)
+	}
+
+	if friends := schema.Properties["friends"]; friends.Items.Ref != "#/components/schemas/user" {
+		t.Fatalf("expected friends to reference user, but got: %+v", friends)
+	}
+
+	if _, found := document.Components.Schemas["details"]; !found {
+		t.Fatalf("expected details to be registered, but got: %v", document.Components.Schemas)
+	}
+}
+
+func TestUndocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/users/:user_id", func(ctx *gin.Context) {})
+	engine.DELETE("/users/:user_id", func(ctx *gin.Context) {})
+
+	document := NewDocument("test", "1.0.0").
+		Add("GET", "/users/:user_id", Operation{OperationId: "getUser"})
+
+	parameters := document.Paths["/users/{user_id}"].operation("GET").Parameters
+	if len(parameters) != 1 || parameters[0].Name != "user_id" || parameters[0].In != "path" {
+		t.Fatalf("expected user_id path parameter, but got: %+v", parameters)
+	}
+
+	undocumented := Undocumented(engine, document)
+	if len(undocumented) != 1 || undocumented[0].Method != "DELETE" || undocumented[0].Path != "/users/:user_id" {
+		t.Fatalf("expected DELETE /users/:user_id to be undocumented, but got: %+v", undocumented)
+	}
+}
@@ -0,0 +1,139 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeArray   = "array"
+	TypeObject  = "object"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+func Integer(minimum int, maximum int) *Schema {
+	return &Schema{
+		Type:    TypeInteger,
+		Minimum: &minimum,
+		Maximum: &maximum,
+	}
+}
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	switch {
+	case t == nil:
+		return &Schema{}
+	case t == timeType:
+		return &Schema{Type: TypeString, Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return d.schemaOf(t.Elem())
+	case reflect.String:
+		return &Schema{Type: TypeString}
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: TypeInteger}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: TypeNumber}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: TypeArray, Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: TypeObject, AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.object(t)
+		}
+		return d.component(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func (d *Document) component(t reflect.Type) *Schema {
+	if name, registered := d.types[t]; registered {
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	name := t.Name()
+	if _, taken := d.Components.Schemas[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+
+	d.types[t] = name
+	d.Components.Schemas[name] = &Schema{}
+	*d.Components.Schemas[name] = *d.object(t)
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (d *Document) object(t reflect.Type) *Schema {
+	schema := &Schema{
+		Type:       TypeObject,
+		Properties: make(map[string]*Schema),
+	}
+	d.addProperties(schema, t)
+
+	return schema
+}
+
+func (d *Document) addProperties(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name, options := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			d.addProperties(schema, field.Type)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = d.schemaOf(field.Type)
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func jsonName(field reflect.StructField) (string, string) {
+	parts := strings.SplitN(field.Tag.Get("json"), ",", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
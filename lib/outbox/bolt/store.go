@@ -0,0 +1,180 @@
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"github.com/frederic-gendebien/pact-poc/lib/outbox"
+	"go.etcd.io/bbolt"
+	"time"
+)
+
+var (
+	outboxBucket    = []byte("outbox")
+	outboxIdsBucket = []byte("outbox_ids")
+	sequencesBucket = []byte("outbox_sequences")
+)
+
+func NewStore(db *bbolt.DB) (*Store, error) {
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		return createBuckets(tx)
+	}); err != nil {
+		return nil, fmt.Errorf("could not initialize outbox: %v", err)
+	}
+
+	return &Store{
+		db: db,
+	}, nil
+}
+
+type Store struct {
+	db *bbolt.DB
+}
+
+func createBuckets(tx *bbolt.Tx) error {
+	for _, bucket := range [][]byte{outboxBucket, outboxIdsBucket, sequencesBucket} {
+		if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) Clear(tx *bbolt.Tx) error {
+	for _, bucket := range [][]byte{outboxBucket, outboxIdsBucket, sequencesBucket} {
+		if err := tx.DeleteBucket(bucket); err != nil {
+			return err
+		}
+	}
+
+	return createBuckets(tx)
+}
+
+func (s *Store) Add(tx *bbolt.Tx, pending ...domain.Envelope) error {
+	records := tx.Bucket(outboxBucket)
+	for _, record := range outbox.NewRecords(pending...) {
+		sequence, err := records.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		if record.Envelope.Metadata.Sequence == 0 {
+			if record.Envelope.Metadata.Sequence, err = nextEntitySequence(tx, record.Envelope.Metadata.EntityId); err != nil {
+				return err
+			}
+		}
+
+		value, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, sequence)
+		if err := records.Put(key, value); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket(outboxIdsBucket).Put([]byte(record.Id), key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nextEntitySequence numbers the events of an entity. The numbering outlives
+// the outbox records, so an entity keeps increasing sequences once its events
+// have been published.
+func nextEntitySequence(tx *bbolt.Tx, entityId string) (int, error) {
+	sequences := tx.Bucket(sequencesBucket)
+	sequence := uint64(1)
+	if value := sequences.Get([]byte(entityId)); value != nil {
+		sequence = binary.BigEndian.Uint64(value) + 1
+	}
+
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, sequence)
+
+	return int(sequence), sequences.Put([]byte(entityId), value)
+}
+
+func (s *Store) PendingEvents(ctx context.Context, limit int) ([]outbox.Record, error) {
+	records := make([]outbox.Record, 0, limit)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		now := time.Now().UTC()
+		blocked := make(map[string]bool)
+		cursor := tx.Bucket(outboxBucket).Cursor()
+		for key, value := cursor.First(); key != nil && len(records) < limit; key, value = cursor.Next() {
+			record := outbox.Record{}
+			if err := json.Unmarshal(value, &record); err != nil {
+				return err
+			}
+
+			entityId := record.Envelope.Metadata.EntityId
+			if blocked[entityId] {
+				continue
+			}
+
+			if !record.IsDue(now) {
+				blocked[entityId] = true
+				continue
+			}
+
+			records = append(records, record)
+		}
+
+		return nil
+	})
+
+	return records, err
+}
+
+func (s *Store) CountPendingEvents(ctx context.Context) (int, error) {
+	count := 0
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		count = tx.Bucket(outboxBucket).Stats().KeyN
+		return nil
+	})
+
+	return count, err
+}
+
+func (s *Store) MarkPublished(ctx context.Context, id string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		key := tx.Bucket(outboxIdsBucket).Get([]byte(id))
+		if key == nil {
+			return fmt.Errorf("outbox record %s was not found", id)
+		}
+
+		if err := tx.Bucket(outboxBucket).Delete(key); err != nil {
+			return err
+		}
+
+		return tx.Bucket(outboxIdsBucket).Delete([]byte(id))
+	})
+}
+
+func (s *Store) MarkFailed(ctx context.Context, id string, cause error, nextAttemptAt time.Time) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		key := tx.Bucket(outboxIdsBucket).Get([]byte(id))
+		if key == nil {
+			return fmt.Errorf("outbox record %s was not found", id)
+		}
+
+		record := outbox.Record{}
+		if err := json.Unmarshal(tx.Bucket(outboxBucket).Get(key), &record); err != nil {
+			return err
+		}
+
+		value, err := json.Marshal(record.FailedWith(cause, nextAttemptAt))
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(outboxBucket).Put(key, value)
+	})
+}
@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"context"
+	"github.com/frederic-gendebien/pact-poc/lib/eventbus/domain"
+	"time"
+)
+
+type Record struct {
+	Id            string          `json:"id"`
+	Envelope      domain.Envelope `json:"envelope"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+}
+
+func NewRecords(pending ...domain.Envelope) []Record {
+	records := make([]Record, 0, len(pending))
+	for _, envelope := range pending {
+		records = append(records, NewRecord(envelope))
+	}
+
+	return records
+}
+
+func NewRecord(envelope domain.Envelope) Record {
+	now := time.Now().UTC()
+	return Record{
+		Id:            envelope.Metadata.EventId,
+		Envelope:      envelope,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+}
+
+func (r Record) IsDue(now time.Time) bool {
+	return !r.NextAttemptAt.After(now)
+}
+
+func (r Record) FailedWith(err error, nextAttemptAt time.Time) Record {
+	r.Attempts++
+	r.LastError = err.Error()
+	r.NextAttemptAt = nextAttemptAt
+
+	return r
+}
+
+type Store interface {
+	PendingEvents(ctx context.Context, limit int) ([]Record, error)
+	CountPendingEvents(ctx context.Context) (int, error)
+	MarkPublished(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id string, cause error, nextAttemptAt time.Time) error
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, event domain.Event) error
+}
+
+type Notifier interface {
+	Notify()
+}
@@ -0,0 +1,99 @@
+package outbox
+
+import (
+	"fmt"
+	"time"
+)
+
+func NewQueue() *Queue {
+	return &Queue{
+		records:   make(map[string]Record),
+		sequences: make(map[string]int),
+	}
+}
+
+type Queue struct {
+	order     []string
+	records   map[string]Record
+	sequences map[string]int
+}
+
+// Add queues the records, numbering the events of each entity that were not
+// sequenced yet. The numbering outlives the records so that an entity keeps
+// increasing sequences after its events have been published.
+func (q *Queue) Add(records ...Record) {
+	for _, record := range records {
+		if _, present := q.records[record.Id]; !present {
+			q.order = append(q.order, record.Id)
+		}
+
+		metadata := &record.Envelope.Metadata
+		if metadata.Sequence == 0 {
+			q.sequences[metadata.EntityId]++
+			metadata.Sequence = q.sequences[metadata.EntityId]
+		}
+		q.records[record.Id] = record
+	}
+}
+
+func (q *Queue) Pending(now time.Time, limit int) []Record {
+	blocked := make(map[string]bool)
+	pending := make([]Record, 0, limit)
+	for _, id := range q.order {
+		if len(pending) >= limit {
+			break
+		}
+
+		record := q.records[id]
+		entityId := record.Envelope.Metadata.EntityId
+		if blocked[entityId] {
+			continue
+		}
+
+		if !record.IsDue(now) {
+			blocked[entityId] = true
+			continue
+		}
+
+		pending = append(pending, record)
+	}
+
+	return pending
+}
+
+func (q *Queue) Count() int {
+	return len(q.order)
+}
+
+func (q *Queue) Remove(id string) error {
+	if _, present := q.records[id]; !present {
+		return fmt.Errorf("outbox record %s was not found", id)
+	}
+
+	delete(q.records, id)
+	for i, recordId := range q.order {
+		if recordId == id {
+			q.order = append(q.order[:i], q.order[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (q *Queue) Fail(id string, cause error, nextAttemptAt time.Time) error {
+	record, present := q.records[id]
+	if !present {
+		return fmt.Errorf("outbox record %s was not found", id)
+	}
+
+	q.records[id] = record.FailedWith(cause, nextAttemptAt)
+
+	return nil
+}
+
+func (q *Queue) Clear() {
+	q.order = nil
+	q.records = make(map[string]Record)
+	q.sequences = make(map[string]int)
+}
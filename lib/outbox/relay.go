@@ -0,0 +1,224 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"sync"
+	"time"
+)
+
+const (
+	relayInterval          = "OUTBOX_RELAY_INTERVAL"
+	relayBatchSize         = "OUTBOX_BATCH_SIZE"
+	relayRetryInitialDelay = "OUTBOX_RETRY_INITIAL_DELAY"
+	relayRetryMaxDelay     = "OUTBOX_RETRY_MAX_DELAY"
+)
+
+func init() {
+	config.Register(
+		config.Property{
+			Name:        relayInterval,
+			Type:        config.TypeDuration,
+			Default:     "1s",
+			Description: "delay between two polls of the outbox",
+		},
+		config.Property{
+			Name:        relayBatchSize,
+			Type:        config.TypeInt,
+			Default:     "100",
+			Description: "maximum number of events published per poll",
+		},
+		config.Property{
+			Name:        relayRetryInitialDelay,
+			Type:        config.TypeDuration,
+			Default:     "1s",
+			Description: "delay before the first retry of a failed publication",
+		},
+		config.Property{
+			Name:        relayRetryMaxDelay,
+			Type:        config.TypeDuration,
+			Default:     "5m",
+			Description: "upper bound of the delay between two retries",
+		},
+	)
+}
+
+func NewRelay(configuration config.Configuration, store Store, publisher Publisher, logger logging.Logger) *Relay {
+	relay := &Relay{
+		logger:     logger,
+		store:      store,
+		publisher:  publisher,
+		flushLock:  &sync.Mutex{},
+		statusLock: &sync.RWMutex{},
+		wakeUp:     make(chan struct{}, 1),
+	}
+	if err := relay.configure(configuration); err != nil {
+		logger.Fatalf("invalid outbox relay configuration: %v", err)
+	}
+
+	return relay
+}
+
+func (r *Relay) configure(configuration config.Configuration) (err error) {
+	if r.interval, err = config.Duration(configuration, relayInterval); err != nil {
+		return err
+	}
+
+	if r.batchSize, err = config.Int(configuration, relayBatchSize); err != nil {
+		return err
+	}
+	if r.batchSize < 1 {
+		return fmt.Errorf("%s must be positive", relayBatchSize)
+	}
+
+	if r.initialDelay, err = config.Duration(configuration, relayRetryInitialDelay); err != nil {
+		return err
+	}
+
+	r.maxDelay, err = config.Duration(configuration, relayRetryMaxDelay)
+
+	return err
+}
+
+type Relay struct {
+	logger       logging.Logger
+	store        Store
+	publisher    Publisher
+	interval     time.Duration
+	batchSize    int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	flushLock    *sync.Mutex
+	statusLock   *sync.RWMutex
+	status       Status
+	wakeUp       chan struct{}
+}
+
+type Status struct {
+	Pending     int        `json:"pending"`
+	Published   int        `json:"published"`
+	Failures    int        `json:"failures"`
+	LastFlushAt *time.Time `json:"last_flush_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+func (r *Relay) Run(ctx context.Context) error {
+	r.logger.Infof("starting outbox relay")
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Flush(ctx); err != nil {
+			r.logger.Warnf("outbox relay flush failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			r.logger.Infof("stopping outbox relay")
+			return ctx.Err()
+		case <-ticker.C:
+		case <-r.wakeUp:
+		}
+	}
+}
+
+func (r *Relay) Notify() {
+	select {
+	case r.wakeUp <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Relay) Flush(ctx context.Context) error {
+	r.flushLock.Lock()
+	defer r.flushLock.Unlock()
+
+	var lastErr error
+	published, failures := 0, 0
+	for {
+		records, err := r.store.PendingEvents(ctx, r.batchSize)
+		if err != nil {
+			return r.flushed(ctx, published, failures, err)
+		}
+
+		blocked := make(map[string]bool)
+		progress := 0
+		for _, record := range records {
+			entityId := record.Envelope.Metadata.EntityId
+			if blocked[entityId] {
+				continue
+			}
+
+			if err := r.publisher.Publish(ctx, record.Envelope); err != nil {
+				blocked[entityId] = true
+				failures++
+				lastErr = fmt.Errorf("could not publish event %s: %v", record.Id, err)
+				r.logger.WithContext(ctx).
+					With(logging.EventId, record.Envelope.Metadata.EventId).
+					With(logging.EventName, record.Envelope.Metadata.Name).
+					Warnf("could not publish event, attempt %d: %v", record.Attempts+1, err)
+				if err := r.store.MarkFailed(ctx, record.Id, err, time.Now().UTC().Add(r.delay(record.Attempts+1))); err != nil {
+					return r.flushed(ctx, published, failures, err)
+				}
+				continue
+			}
+
+			if err := r.store.MarkPublished(ctx, record.Id); err != nil {
+				return r.flushed(ctx, published, failures, err)
+			}
+			published++
+			progress++
+		}
+
+		if progress == 0 || len(records) < r.batchSize {
+			return r.flushed(ctx, published, failures, lastErr)
+		}
+	}
+}
+
+func (r *Relay) Status(ctx context.Context) (Status, error) {
+	pending, err := r.store.CountPendingEvents(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+
+	r.statusLock.RLock()
+	defer r.statusLock.RUnlock()
+
+	status := r.status
+	status.Pending = pending
+
+	return status, nil
+}
+
+func (r *Relay) flushed(ctx context.Context, published int, failures int, err error) error {
+	r.statusLock.Lock()
+	defer r.statusLock.Unlock()
+
+	now := time.Now().UTC()
+	r.status.LastFlushAt = &now
+	r.status.Published += published
+	r.status.Failures += failures
+	if err != nil {
+		r.status.LastError = err.Error()
+	} else if failures == 0 {
+		r.status.LastError = ""
+	}
+
+	return err
+}
+
+func (r *Relay) delay(attempt int) time.Duration {
+	delay := r.initialDelay
+	for i := 1; i < attempt && delay < r.maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > r.maxDelay {
+		return r.maxDelay
+	}
+
+	return delay
+}
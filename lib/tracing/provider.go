@@ -0,0 +1,148 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/frederic-gendebien/pact-poc/lib/config"
+	"github.com/frederic-gendebien/pact-poc/lib/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"io"
+	"os"
+)
+
+const (
+	Exporter         = "TRACING_EXPORTER"
+	ExporterNone     = "none"
+	ExporterStdout   = "stdout"
+	ExporterFile     = "file"
+	ExporterOTLP     = "otlp"
+	FilePath         = "TRACING_FILE_PATH"
+	OTLPEndpoint     = "TRACING_OTLP_ENDPOINT"
+	OTLPInsecure     = "TRACING_OTLP_INSECURE"
+	defaultExporter  = ExporterNone
+	defaultOTLPInsec = "false"
+)
+
+func init() {
+	config.Register(config.Property{
+		Name:        Exporter,
+		Default:     defaultExporter,
+		Allowed:     []string{ExporterNone, ExporterStdout, ExporterFile, ExporterOTLP},
+		Description: "where finished spans are exported",
+	})
+	config.Register(config.When(Exporter, ExporterFile, config.Property{
+		Name:        FilePath,
+		Required:    true,
+		Description: "file the spans are appended to",
+	})...)
+	config.Register(config.When(Exporter, ExporterOTLP,
+		config.Property{
+			Name:        OTLPEndpoint,
+			Required:    true,
+			Description: "host:port of the OTLP/HTTP collector",
+		},
+		config.Property{
+			Name:        OTLPInsecure,
+			Type:        config.TypeBool,
+			Default:     defaultOTLPInsec,
+			Description: "disables TLS towards the OTLP collector",
+		},
+	)...)
+}
+
+func NewProvider(configuration config.Configuration, logger logging.Logger, serviceName string) *Provider {
+	mode := config.String(configuration, Exporter)
+	if mode == ExporterNone {
+		logger.Infof("tracing is disabled")
+		return &Provider{}
+	}
+
+	exporter, output, err := newExporter(configuration, mode)
+	if err != nil {
+		logger.Fatalf("could not create %s trace exporter: %v", mode, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	logger.Infof("tracing with %s exporter", mode)
+
+	return &Provider{
+		provider: provider,
+		output:   output,
+	}
+}
+
+func newExporter(configuration config.Configuration, mode string) (sdktrace.SpanExporter, io.Closer, error) {
+	switch mode {
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exporter, nil, err
+	case ExporterFile:
+		path, err := config.MandatoryString(configuration, FilePath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		return exporter, file, err
+	case ExporterOTLP:
+		endpoint, err := config.MandatoryString(configuration, OTLPEndpoint)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		insecure, err := config.Bool(configuration, OTLPInsecure)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		options := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(endpoint),
+		}
+		if insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err := otlptracehttp.New(context.Background(), options...)
+		return exporter, nil, err
+	default:
+		return nil, nil, fmt.Errorf("unknown tracing exporter: %s", mode)
+	}
+}
+
+type Provider struct {
+	provider *sdktrace.TracerProvider
+	output   io.Closer
+}
+
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.provider == nil {
+		return nil
+	}
+
+	if err := p.provider.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	if p.output != nil {
+		return p.output.Close()
+	}
+
+	return nil
+}
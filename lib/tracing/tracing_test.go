@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func TestInjectAndExtract(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	if carrier := Inject(context.Background()); carrier != nil {
+		t.Fatalf("expected no trace context without span, but got: %v", carrier)
+	}
+
+	ctx, parent := Start(context.Background(), "parent")
+	carrier := Inject(ctx)
+	if carrier["traceparent"] == "" {
+		t.Fatalf("expected traceparent to be injected, but got: %v", carrier)
+	}
+
+	_, child := Start(Extract(context.Background(), carrier), "child")
+	End(child, errors.New("failed"))
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 ended spans, but got: %d", len(spans))
+	}
+
+	if spans[0].Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("expected child to be parented by %s, but got: %s", parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	}
+
+	if spans[0].Status().Code != codes.Error || spans[1].Status().Code != codes.Unset {
+		t.Fatalf("expected only child to be in error, but got: %v and %v", spans[0].Status(), spans[1].Status())
+	}
+
+	if trace.SpanContextFromContext(Extract(context.Background(), nil)).IsValid() {
+		t.Fatal("expected no span context from an empty carrier")
+	}
+}